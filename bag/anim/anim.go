@@ -0,0 +1,251 @@
+// Package anim assembles thing animations from video.bag sprites and exports them as animated GIF or APNG.
+package anim
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/noxworld-dev/opennox-lib/bag"
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+	"github.com/noxworld-dev/opennox-lib/player"
+	"github.com/noxworld-dev/opennox-lib/things"
+)
+
+const (
+	// TicksPerSecond is the game frame rate used to convert animation speed to frame delays.
+	TicksPerSecond = 30
+	// randomFrames is the number of frames generated for each source frame of a random animation.
+	randomFrames = 4
+)
+
+// Options for building animation sequences.
+type Options struct {
+	// Delay overrides the frame delay for all sequences. If not set, it's derived from animation speed.
+	Delay time.Duration
+	// Seed for random animations. The same seed always generates the same sequence.
+	Seed int64
+}
+
+// Sequence is a single animation sequence: one animation of a thing for one direction.
+type Sequence struct {
+	// Name of the sequence, unique for the thing draw. It is empty for draws with only one animation.
+	Name  string
+	Kind  things.AnimationKind
+	Delay time.Duration
+	// Frames with the original draw offsets.
+	Frames []*pcx.Image
+}
+
+// Loop returns true if the sequence must be played in a loop.
+func (s *Sequence) Loop() bool {
+	switch s.Kind {
+	case things.AnimationOneShot, things.AnimationOneShotRemove:
+		return false
+	}
+	return true
+}
+
+// Bounds returns the union of all frame rectangles, relative to the object origin.
+func (s *Sequence) Bounds() image.Rectangle {
+	var r image.Rectangle
+	for _, fr := range s.Frames {
+		r = r.Union(frameRect(fr))
+	}
+	return r
+}
+
+func frameRect(fr *pcx.Image) image.Rectangle {
+	sz := fr.Bounds().Size()
+	return image.Rectangle{Min: fr.Point, Max: fr.Point.Add(sz)}
+}
+
+// Images renders all frames into images of the same size, preserving offsets between frames.
+func (s *Sequence) Images() []*image.NRGBA {
+	r := s.Bounds()
+	out := make([]*image.NRGBA, 0, len(s.Frames))
+	for _, fr := range s.Frames {
+		dst := image.NewNRGBA(image.Rectangle{Max: r.Size()})
+		fr2 := frameRect(fr).Sub(r.Min)
+		draw.Draw(dst, fr2, fr.Image, fr.Bounds().Min, draw.Src)
+		out = append(out, dst)
+	}
+	return out
+}
+
+// frameDelay converts animation speed field to frame delay.
+// The field is interpreted as the number of additional game ticks each frame is shown.
+func frameDelay(field byte) time.Duration {
+	return time.Duration(field+1) * time.Second / TicksPerSecond
+}
+
+type builder struct {
+	opts Options
+	f    *bag.File
+	d    *things.Data
+	imgs map[int]*pcx.Image
+	out  []*Sequence
+}
+
+// Sequences builds all animation sequences of the thing draw.
+// Thing data is used to resolve named image references.
+//
+// Monster and player draws generate one sequence per animation and direction.
+// Draws without animations return no sequences.
+func Sequences(f *bag.File, d *things.Data, th *things.Thing, opts *Options) ([]*Sequence, error) {
+	b := &builder{f: f, d: d, imgs: make(map[int]*pcx.Image)}
+	if opts != nil {
+		b.opts = *opts
+	}
+	if err := b.addDraw(th.Draw); err != nil {
+		return nil, fmt.Errorf("thing %q: %w", th.Name, err)
+	}
+	return b.out, nil
+}
+
+func (b *builder) addDraw(d things.Draw) error {
+	switch d := d.(type) {
+	case things.AnimateDraw:
+		return b.addAnim("", &d.Anim)
+	case things.GlyphDraw:
+		return b.addAnim("", &d.Anim)
+	case things.WeaponAnimateDraw:
+		return b.addAnim("", &d.Anim)
+	case things.ArmorAnimateDraw:
+		return b.addAnim("", &d.Anim)
+	case things.FlagDraw:
+		return b.addAnim("", &d.Anim)
+	case things.SphericalShieldDraw:
+		return b.addAnim("", &d.Anim)
+	case things.SummonEffectDraw:
+		return b.addAnim("", &d.Anim)
+	case things.ConditionalAnimateDraw:
+		return b.addAnims(d.Anims)
+	case things.MonsterGeneratorDraw:
+		return b.addAnims(d.Anims)
+	case things.AnimateStateDraw:
+		for _, a := range d.Anims {
+			name := a.Name
+			if name == "" {
+				name = strconv.FormatUint(uint64(a.State), 10)
+			}
+			if err := b.addAnim(name, &a.Anim); err != nil {
+				return err
+			}
+		}
+	case things.VectorAnimateDraw:
+		return b.addDirs("", d.Anim.Kind, d.Anim.Field, d.Anim.Frames)
+	case things.ReleasedSoulDraw:
+		return b.addDirs("", d.Anim.Kind, d.Anim.Field, d.Anim.Frames)
+	case things.MonsterDraw:
+		return b.addMonster(d.Anims)
+	case things.MaidenDraw:
+		return b.addMonster(d.Anims)
+	case things.PlayerDraw:
+		keys := make([]string, 0, len(d.Anims))
+		for k := range d.Anims {
+			keys = append(keys, string(k))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			a := d.Anims[player.AnimType(k)]
+			parts := make([]string, 0, len(a.Parts))
+			for p := range a.Parts {
+				parts = append(parts, string(p))
+			}
+			sort.Strings(parts)
+			for _, p := range parts {
+				// player animations are not looped by the engine itself, but it's more useful to see them in a loop
+				if err := b.addDirs(k+"_"+p, things.AnimationLoop, a.Field8, a.Parts[player.AnimPart(p)]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (b *builder) addAnims(list []things.Animation) error {
+	for i := range list {
+		if err := b.addAnim(strconv.Itoa(i), &list[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) addMonster(list []things.MonsterAnimation) error {
+	for _, a := range list {
+		if err := b.addDirs(a.Type.String(), a.Kind, a.Field10, a.Frames); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) addDirs(name string, kind things.AnimationKind, field byte, dirs [8][]things.ImageRef) error {
+	for dir, frames := range dirs {
+		dname := strconv.Itoa(dir)
+		if name != "" {
+			dname = name + "_" + dname
+		}
+		if err := b.addAnim(dname, &things.Animation{Field: field, Kind: kind, Frames: frames}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) addAnim(name string, a *things.Animation) error {
+	if len(a.Frames) == 0 {
+		return nil
+	}
+	s := &Sequence{Name: name, Kind: a.Kind, Delay: b.opts.Delay}
+	if s.Delay == 0 {
+		s.Delay = frameDelay(a.Field)
+	}
+	frames := a.Frames
+	if a.Kind == things.AnimationRandom && len(frames) > 1 {
+		rnd := rand.New(rand.NewSource(b.opts.Seed))
+		frames = make([]things.ImageRef, randomFrames*len(a.Frames))
+		for i := range frames {
+			frames[i] = a.Frames[rnd.Intn(len(a.Frames))]
+		}
+	}
+	for _, ref := range frames {
+		img, err := b.image(ref)
+		if err != nil {
+			return err
+		}
+		s.Frames = append(s.Frames, img)
+	}
+	b.out = append(b.out, s)
+	return nil
+}
+
+func (b *builder) image(ref things.ImageRef) (*pcx.Image, error) {
+	ind, ok := b.d.ImageIndex(ref)
+	if !ok {
+		return nil, fmt.Errorf("cannot resolve image %q", ref.Name)
+	}
+	if img, ok := b.imgs[ind]; ok {
+		return img, nil
+	}
+	imgs, err := b.f.Images()
+	if err != nil {
+		return nil, err
+	}
+	if ind >= len(imgs) {
+		return nil, fmt.Errorf("invalid sprite index: %d", ind)
+	}
+	img, err := imgs[ind].Decode()
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode sprite %q (%d): %w", imgs[ind].Name, ind, err)
+	}
+	b.imgs[ind] = img
+	return img, nil
+}
@@ -0,0 +1,115 @@
+package anim
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/bag"
+	"github.com/noxworld-dev/opennox-lib/bag/internal/bagtest"
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+	"github.com/noxworld-dev/opennox-lib/things"
+)
+
+func openTestBag(t testing.TB) *bag.File {
+	path := filepath.Join(t.TempDir(), "video.bag")
+	w, err := bag.Create(path, "")
+	must.NoError(t, err)
+	err = w.WriteSegment([]bag.SegmentImage{
+		{Name: "a.pcx", Type: 3, Data: pcx.Encode(bagtest.Sprite(4, 3, color.NRGBA{R: 248, A: 255}, image.Pt(-2, -3)))},
+		{Name: "b.pcx", Type: 3, Data: pcx.Encode(bagtest.Sprite(2, 5, color.NRGBA{G: 248, A: 255}, image.Pt(1, 0)))},
+	})
+	must.NoError(t, err)
+	err = w.Close()
+	must.NoError(t, err)
+	f, err := bag.Open(path)
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+	return f
+}
+
+func TestSequences(t *testing.T) {
+	f := openTestBag(t)
+	d := &things.Data{Images: []things.Image{
+		{Name: "BAnim", Ani: &things.Animation{Frames: []things.ImageRef{{Ind: 1}}}},
+	}}
+	th := &things.Thing{Name: "Test", Draw: things.AnimateDraw{Anim: things.Animation{
+		Field: 2,
+		Kind:  things.AnimationOneShot,
+		Frames: []things.ImageRef{
+			{Ind: 0},
+			{Name: "BAnim"},
+		},
+	}}}
+	seqs, err := Sequences(f, d, th, nil)
+	must.NoError(t, err)
+	must.Len(t, 1, seqs)
+	s := seqs[0]
+	must.EqOp(t, "", s.Name)
+	must.False(t, s.Loop())
+	must.EqOp(t, 100*time.Millisecond, s.Delay)
+	must.Len(t, 2, s.Frames)
+	must.EqOp(t, image.Rect(-2, -3, 3, 5), s.Bounds())
+
+	imgs := s.Images()
+	must.EqOp(t, color.NRGBA{R: 248, A: 255}, imgs[0].NRGBAAt(0, 0))
+	must.EqOp(t, color.NRGBA{}, imgs[0].NRGBAAt(3, 3))
+	must.EqOp(t, color.NRGBA{G: 248, A: 255}, imgs[1].NRGBAAt(3, 3))
+
+	var buf bytes.Buffer
+	err = s.WriteGIF(&buf)
+	must.NoError(t, err)
+	g, err := gif.DecodeAll(&buf)
+	must.NoError(t, err)
+	must.Len(t, 2, g.Image)
+	must.EqOp(t, -1, g.LoopCount)
+	must.EqOp(t, 10, g.Delay[0])
+	must.EqOp(t, 5, g.Config.Width)
+	must.EqOp(t, 8, g.Config.Height)
+
+	buf.Reset()
+	err = s.WriteAPNG(&buf)
+	must.NoError(t, err)
+	must.True(t, bytes.Contains(buf.Bytes(), []byte("acTL")))
+	must.True(t, bytes.Contains(buf.Bytes(), []byte("fdAT")))
+	// regular PNG decoders must show the first frame
+	img, err := png.Decode(&buf)
+	must.NoError(t, err)
+	must.EqOp(t, image.Pt(5, 8), img.Bounds().Size())
+	r, g2, b, a := img.At(0, 0).RGBA()
+	must.Eq(t, [4]uint32{0xf8f8, 0, 0, 0xffff}, [4]uint32{r, g2, b, a})
+}
+
+func TestSequencesMonster(t *testing.T) {
+	f := openTestBag(t)
+	d := &things.Data{}
+	var frames [8][]things.ImageRef
+	frames[0] = []things.ImageRef{{Ind: 0}, {Ind: 1}}
+	frames[3] = []things.ImageRef{{Ind: 1}}
+	th := &things.Thing{Name: "Test", Draw: things.MonsterDraw{Anims: []things.MonsterAnimation{
+		{Type: things.MonsterAnimIdle, Kind: things.AnimationLoop, Frames: frames},
+		{Type: things.MonsterAnimDie, Kind: things.AnimationRandom, Frames: frames},
+	}}}
+	seqs, err := Sequences(f, d, th, &Options{Delay: time.Second})
+	must.NoError(t, err)
+	var names []string
+	for _, s := range seqs {
+		names = append(names, s.Name)
+		must.EqOp(t, time.Second, s.Delay)
+	}
+	must.Eq(t, []string{"IDLE_0", "IDLE_3", "DIE_0", "DIE_3"}, names)
+	must.True(t, seqs[0].Loop())
+	must.Len(t, 2*randomFrames, seqs[2].Frames)
+
+	th.Draw = things.StaticDraw{Img: things.ImageRef{Ind: 0}}
+	seqs, err = Sequences(f, d, th, nil)
+	must.NoError(t, err)
+	must.Len(t, 0, seqs)
+}
@@ -0,0 +1,182 @@
+package anim
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// GIF converts the sequence to an animated GIF.
+//
+// If all frames use at most 255 colors, the palette is exact. Otherwise, colors are dithered to a fixed palette.
+func (s *Sequence) GIF() *gif.GIF {
+	frames := s.Images()
+	pal := gifPalette(frames)
+	g := &gif.GIF{LoopCount: -1}
+	if s.Loop() {
+		g.LoopCount = 0
+	}
+	delay := int(s.Delay / (10 * time.Millisecond))
+	if delay <= 0 {
+		delay = 1
+	}
+	for _, fr := range frames {
+		dst := image.NewPaletted(fr.Rect, pal)
+		if len(pal) == 256 {
+			draw.FloydSteinberg.Draw(dst, fr.Rect, fr, image.Point{})
+		} else {
+			draw.Draw(dst, fr.Rect, fr, image.Point{}, draw.Src)
+		}
+		g.Image = append(g.Image, dst)
+		g.Delay = append(g.Delay, delay)
+		g.Disposal = append(g.Disposal, gif.DisposalBackground)
+	}
+	if len(frames) != 0 {
+		sz := frames[0].Rect.Size()
+		g.Config = image.Config{ColorModel: pal, Width: sz.X, Height: sz.Y}
+	}
+	return g
+}
+
+// gifPalette returns a palette for frames. Index 0 is always transparent.
+func gifPalette(frames []*image.NRGBA) color.Palette {
+	pal := color.Palette{color.NRGBA{}}
+	seen := make(map[color.NRGBA]struct{})
+	for _, fr := range frames {
+		for i := 0; i+3 < len(fr.Pix); i += 4 {
+			c := color.NRGBA{R: fr.Pix[i+0], G: fr.Pix[i+1], B: fr.Pix[i+2], A: fr.Pix[i+3]}
+			if c.A == 0 {
+				continue
+			}
+			c.A = 0xff // GIF doesn't support partial transparency
+			if _, ok := seen[c]; ok {
+				continue
+			}
+			if len(pal) == 255 {
+				// too many colors, use a generic palette with a transparent color
+				return append(color.Palette{color.NRGBA{}}, palette.Plan9[:255]...)
+			}
+			seen[c] = struct{}{}
+			pal = append(pal, c)
+		}
+	}
+	return pal
+}
+
+// WriteGIF writes the sequence as an animated GIF.
+func (s *Sequence) WriteGIF(w io.Writer) error {
+	return gif.EncodeAll(w, s.GIF())
+}
+
+// WriteAPNG writes the sequence as an animated PNG.
+func (s *Sequence) WriteAPNG(w io.Writer) error {
+	frames := s.Images()
+	if len(frames) == 0 {
+		return nil
+	}
+	sz := frames[0].Rect.Size()
+	e := &apngEncoder{w: bufio.NewWriter(w)}
+	e.write([]byte("\x89PNG\r\n\x1a\n"))
+
+	var b [26]byte
+	binary.BigEndian.PutUint32(b[0:], uint32(sz.X))
+	binary.BigEndian.PutUint32(b[4:], uint32(sz.Y))
+	b[8] = 8  // bit depth
+	b[9] = 6  // RGBA
+	b[10] = 0 // compression
+	b[11] = 0 // filter
+	b[12] = 0 // no interlace
+	e.chunk("IHDR", b[:13])
+
+	plays := uint32(1)
+	if s.Loop() {
+		plays = 0 // infinite
+	}
+	binary.BigEndian.PutUint32(b[0:], uint32(len(frames)))
+	binary.BigEndian.PutUint32(b[4:], plays)
+	e.chunk("acTL", b[:8])
+
+	ms := s.Delay.Milliseconds()
+	if ms > 0xffff {
+		ms = 0xffff
+	}
+	for i, fr := range frames {
+		binary.BigEndian.PutUint32(b[0:], e.seq)
+		binary.BigEndian.PutUint32(b[4:], uint32(sz.X))
+		binary.BigEndian.PutUint32(b[8:], uint32(sz.Y))
+		binary.BigEndian.PutUint32(b[12:], 0) // x offset
+		binary.BigEndian.PutUint32(b[16:], 0) // y offset
+		binary.BigEndian.PutUint16(b[20:], uint16(ms))
+		binary.BigEndian.PutUint16(b[22:], 1000)
+		b[24] = 0 // dispose: none, each frame covers the whole canvas
+		b[25] = 0 // blend: source
+		e.seq++
+		e.chunk("fcTL", b[:26])
+		data := e.compress(fr)
+		if i == 0 {
+			e.chunk("IDAT", data)
+		} else {
+			var sb [4]byte
+			binary.BigEndian.PutUint32(sb[:], e.seq)
+			e.seq++
+			e.chunk("fdAT", append(sb[:], data...))
+		}
+	}
+	e.chunk("IEND", nil)
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type apngEncoder struct {
+	w   *bufio.Writer
+	seq uint32
+	buf bytes.Buffer
+	err error
+}
+
+func (e *apngEncoder) write(p []byte) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.Write(p)
+}
+
+func (e *apngEncoder) chunk(name string, data []byte) {
+	var hdr [8]byte
+	binary.BigEndian.PutUint32(hdr[:4], uint32(len(data)))
+	copy(hdr[4:], name)
+	crc := crc32.NewIEEE()
+	crc.Write(hdr[4:])
+	crc.Write(data)
+	e.write(hdr[:])
+	e.write(data)
+	binary.BigEndian.PutUint32(hdr[:4], crc.Sum32())
+	e.write(hdr[:4])
+}
+
+// compress encodes image rows without filtering, as expected by IDAT and fdAT chunks.
+func (e *apngEncoder) compress(img *image.NRGBA) []byte {
+	e.buf.Reset()
+	zw := zlib.NewWriter(&e.buf)
+	sz := img.Rect.Size()
+	for y := 0; y < sz.Y; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+4*sz.X]
+		zw.Write([]byte{0}) // filter type
+		zw.Write(row)
+	}
+	if err := zw.Close(); err != nil && e.err == nil {
+		e.err = err
+	}
+	return bytes.Clone(e.buf.Bytes())
+}
@@ -0,0 +1,232 @@
+// Package atlas packs Nox sprites into texture atlases.
+package atlas
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/noxworld-dev/opennox-lib/bag"
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+)
+
+const (
+	// DefaultMaxSize is the default maximal size of the atlas page.
+	DefaultMaxSize = 2048
+	// DefaultPadding is the default number of transparent pixels between sprites.
+	DefaultPadding = 1
+)
+
+// Options for the atlas Builder.
+type Options struct {
+	// MaxSize is the maximal width and height of a single atlas page. DefaultMaxSize is used if not set.
+	MaxSize int
+	// Padding is the number of transparent pixels between sprites. DefaultPadding is used if not set.
+	// Negative value disables padding.
+	Padding int
+}
+
+// Frame describes a single sprite in the atlas.
+type Frame struct {
+	// Name of the sprite in video.bag.
+	Name string `json:"name"`
+	// Index of the sprite in video.bag.
+	Index int `json:"index"`
+	// Page is an index of the atlas page that contains the sprite.
+	Page int `json:"page"`
+	// Rect is the sprite rectangle in the atlas page.
+	Rect Rect `json:"rect"`
+	// ImageMeta contains sprite type and the draw offset.
+	pcx.ImageMeta
+}
+
+// Rect is a rectangle in the atlas page.
+type Rect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// Image returns rectangle as image.Rectangle.
+func (r Rect) Image() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H)
+}
+
+// Atlas is a set of packed sprites.
+type Atlas struct {
+	Pages  []*image.NRGBA
+	Frames []Frame
+}
+
+// Meta is the JSON metadata of the atlas.
+type Meta struct {
+	// Pages lists file names of atlas pages.
+	Pages  []string `json:"pages"`
+	Frames []Frame  `json:"frames"`
+}
+
+type sprite struct {
+	name string
+	ind  int
+	img  *pcx.Image
+}
+
+// Builder collects sprites and packs them into an Atlas.
+type Builder struct {
+	opts    Options
+	sprites []sprite
+	seen    map[int]struct{}
+}
+
+// NewBuilder creates a new atlas builder.
+func NewBuilder(opts *Options) *Builder {
+	b := &Builder{seen: make(map[int]struct{})}
+	if opts != nil {
+		b.opts = *opts
+	}
+	if b.opts.MaxSize <= 0 {
+		b.opts.MaxSize = DefaultMaxSize
+	}
+	if b.opts.Padding == 0 {
+		b.opts.Padding = DefaultPadding
+	} else if b.opts.Padding < 0 {
+		b.opts.Padding = 0
+	}
+	return b
+}
+
+// Add a decoded sprite to the atlas. Sprites with the same index are added only once.
+func (b *Builder) Add(name string, ind int, img *pcx.Image) error {
+	if _, ok := b.seen[ind]; ok {
+		return nil
+	}
+	sz := img.Bounds().Size()
+	if max := b.opts.MaxSize; sz.X > max || sz.Y > max {
+		return fmt.Errorf("sprite %q (%d) is too large: %dx%d", name, ind, sz.X, sz.Y)
+	}
+	b.seen[ind] = struct{}{}
+	b.sprites = append(b.sprites, sprite{name: name, ind: ind, img: img})
+	return nil
+}
+
+// AddImage decodes a sprite from video.bag and adds it to the atlas.
+func (b *Builder) AddImage(img *bag.ImageRec) error {
+	if _, ok := b.seen[img.Index]; ok {
+		return nil
+	}
+	pimg, err := img.Decode()
+	if err != nil {
+		return fmt.Errorf("cannot decode sprite %q (%d): %w", img.Name, img.Index, err)
+	}
+	return b.Add(img.Name, img.Index, pimg)
+}
+
+// Build packs all added sprites into atlas pages.
+//
+// Sprites are packed into horizontal shelves, from the tallest to the shortest.
+// Frames are returned in the same order sprites were added.
+func (b *Builder) Build() (*Atlas, error) {
+	if len(b.sprites) == 0 {
+		return nil, errors.New("no sprites to pack")
+	}
+	order := make([]int, len(b.sprites))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		si := b.sprites[order[i]].img.Bounds().Size()
+		sj := b.sprites[order[j]].img.Bounds().Size()
+		if si.Y != sj.Y {
+			return si.Y > sj.Y
+		}
+		return si.X > sj.X
+	})
+	var (
+		pad  = b.opts.Padding
+		max  = b.opts.MaxSize
+		a    = &Atlas{Frames: make([]Frame, len(b.sprites))}
+		page = 0
+		pos  image.Point
+		rowH int
+		used []image.Point // used size of each page
+	)
+	used = append(used, image.Point{})
+	for _, i := range order {
+		s := b.sprites[i]
+		sz := s.img.Bounds().Size()
+		if pos.X+sz.X > max {
+			// next shelf
+			pos.X = 0
+			pos.Y += rowH + pad
+			rowH = 0
+		}
+		if pos.Y+sz.Y > max {
+			// next page
+			page++
+			used = append(used, image.Point{})
+			pos = image.Point{}
+			rowH = 0
+		}
+		a.Frames[i] = Frame{
+			Name:      s.name,
+			Index:     s.ind,
+			Page:      page,
+			Rect:      Rect{X: pos.X, Y: pos.Y, W: sz.X, H: sz.Y},
+			ImageMeta: s.img.ImageMeta,
+		}
+		if sz.Y > rowH {
+			rowH = sz.Y
+		}
+		u := &used[page]
+		if v := pos.X + sz.X; v > u.X {
+			u.X = v
+		}
+		if v := pos.Y + sz.Y; v > u.Y {
+			u.Y = v
+		}
+		pos.X += sz.X + pad
+	}
+	a.Pages = make([]*image.NRGBA, len(used))
+	for i, sz := range used {
+		a.Pages[i] = image.NewNRGBA(image.Rectangle{Max: sz})
+	}
+	for i, fr := range a.Frames {
+		img := b.sprites[i].img
+		draw.Draw(a.Pages[fr.Page], fr.Rect.Image(), img.Image, img.Bounds().Min, draw.Src)
+	}
+	return a, nil
+}
+
+// WriteFiles writes atlas pages as PNG files and the metadata as JSON file into a given directory.
+// Pages are named as name_N.png and the metadata is written to name.json.
+func (a *Atlas) WriteFiles(dir, name string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	meta := Meta{Frames: a.Frames}
+	var buf bytes.Buffer
+	for i, page := range a.Pages {
+		buf.Reset()
+		if err := png.Encode(&buf, page); err != nil {
+			return err
+		}
+		fname := fmt.Sprintf("%s_%d.png", name, i)
+		if err := os.WriteFile(filepath.Join(dir, fname), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		meta.Pages = append(meta.Pages, fname)
+	}
+	data, err := json.MarshalIndent(meta, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name+".json"), data, 0644)
+}
@@ -0,0 +1,71 @@
+package atlas
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/bag/internal/bagtest"
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+)
+
+func TestBuild(t *testing.T) {
+	b := NewBuilder(&Options{MaxSize: 32, Padding: 1})
+	sprites := []*pcx.Image{
+		bagtest.Sprite(10, 5, color.NRGBA{R: 255, A: 255}, image.Pt(1, 2)),
+		bagtest.Sprite(20, 20, color.NRGBA{G: 255, A: 255}, image.Pt(-3, 4)),
+		bagtest.Sprite(15, 10, color.NRGBA{B: 255, A: 255}, image.Pt(0, 0)),
+		bagtest.Sprite(30, 30, color.NRGBA{R: 255, G: 255, A: 255}, image.Pt(5, 5)),
+		bagtest.Sprite(8, 8, color.NRGBA{R: 255, B: 255, A: 255}, image.Pt(0, 1)),
+	}
+	for i, img := range sprites {
+		err := b.Add("sprite", 100+i, img)
+		must.NoError(t, err)
+	}
+	err := b.Add("dup", 100, sprites[0])
+	must.NoError(t, err)
+	err = b.Add("big", 200, bagtest.Sprite(33, 1, color.NRGBA{}, image.Point{}))
+	must.Error(t, err)
+
+	a, err := b.Build()
+	must.NoError(t, err)
+	must.Len(t, len(sprites), a.Frames)
+	must.Len(t, 3, a.Pages)
+
+	for i, fr := range a.Frames {
+		img := sprites[i]
+		must.EqOp(t, 100+i, fr.Index)
+		must.Eq(t, img.ImageMeta, fr.ImageMeta)
+		r := fr.Rect.Image()
+		must.EqOp(t, img.Bounds().Size(), r.Size())
+		page := a.Pages[fr.Page]
+		must.True(t, r.In(page.Bounds()))
+		must.Eq(t, img.At(0, 0), page.At(r.Min.X, r.Min.Y))
+		must.Eq(t, img.At(r.Dx()-1, r.Dy()-1), page.At(r.Max.X-1, r.Max.Y-1))
+		for j, fr2 := range a.Frames {
+			if i != j && fr.Page == fr2.Page {
+				must.False(t, r.Overlaps(fr2.Rect.Image()))
+			}
+		}
+	}
+
+	dir := t.TempDir()
+	err = a.WriteFiles(dir, "atlas")
+	must.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "atlas.json"))
+	must.NoError(t, err)
+	var meta Meta
+	err = json.Unmarshal(data, &meta)
+	must.NoError(t, err)
+	must.Eq(t, []string{"atlas_0.png", "atlas_1.png", "atlas_2.png"}, meta.Pages)
+	must.Eq(t, a.Frames, meta.Frames)
+	for _, name := range meta.Pages {
+		_, err = os.Stat(filepath.Join(dir, name))
+		must.NoError(t, err)
+	}
+}
@@ -0,0 +1,62 @@
+package atlas
+
+import (
+	"fmt"
+
+	"github.com/noxworld-dev/opennox-lib/bag"
+	"github.com/noxworld-dev/opennox-lib/things"
+)
+
+// SelectNames selects sprites from video.bag by names.
+func SelectNames(f *bag.File, names ...string) ([]*bag.ImageRec, error) {
+	out := make([]*bag.ImageRec, 0, len(names))
+	for _, name := range names {
+		img, err := f.ImageByName(name)
+		if err != nil {
+			return nil, err
+		} else if img == nil {
+			return nil, fmt.Errorf("cannot find sprite: %q", name)
+		}
+		out = append(out, img)
+	}
+	return out, nil
+}
+
+// SelectRange selects sprites from video.bag by index range. Both from and to are inclusive.
+func SelectRange(f *bag.File, from, to int) ([]*bag.ImageRec, error) {
+	imgs, err := f.Images()
+	if err != nil {
+		return nil, err
+	}
+	if from < 0 || from > to || to >= len(imgs) {
+		return nil, fmt.Errorf("invalid sprite range: [%d, %d] (total %d)", from, to, len(imgs))
+	}
+	return imgs[from : to+1], nil
+}
+
+// SelectThing selects all sprites used by the thing draw animations.
+// Thing data is used to resolve named image references.
+func SelectThing(f *bag.File, d *things.Data, th *things.Thing) ([]*bag.ImageRec, error) {
+	imgs, err := f.Images()
+	if err != nil {
+		return nil, err
+	}
+	refs := things.DrawImages(th.Draw)
+	out := make([]*bag.ImageRec, 0, len(refs))
+	seen := make(map[int]struct{}, len(refs))
+	for _, ref := range refs {
+		ind, ok := d.ImageIndex(ref)
+		if !ok {
+			return nil, fmt.Errorf("thing %q: cannot resolve image %q", th.Name, ref.Name)
+		}
+		if ind >= len(imgs) {
+			return nil, fmt.Errorf("thing %q: invalid sprite index: %d", th.Name, ind)
+		}
+		if _, ok := seen[ind]; ok {
+			continue
+		}
+		seen[ind] = struct{}{}
+		out = append(out, imgs[ind])
+	}
+	return out, nil
+}
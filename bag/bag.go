@@ -17,6 +17,9 @@ import (
 
 var endiness = binary.LittleEndian
 
+// File is an opened video.bag file with its index.
+//
+// File is not safe for concurrent use. Use Cache for accessing images from multiple goroutines.
 type File struct {
 	idx io.ReadSeeker
 	bag io.ReadSeeker
@@ -104,9 +107,9 @@ func (f *File) readHeader() error {
 	switch magic {
 	default:
 		return fmt.Errorf("invalid magic: %x", magic)
-	case 0xFAEDBCEB:
+	case magicComp:
 		f.hasComp = true
-	case 0xFAEDBCEA:
+	case magicNoComp:
 		f.hasComp = false
 	}
 	f.size = endiness.Uint32(b[4:])
@@ -292,10 +295,8 @@ func (f *File) readAll() error {
 	return nil
 }
 
-func (seg *Segment) open() (io.Reader, error) {
-	if seg.data != nil {
-		return bytes.NewReader(seg.data), nil
-	}
+// readData reads and decompresses segment data without caching it.
+func (seg *Segment) readData() ([]byte, error) {
 	f := seg.f
 	_, err := f.bag.Seek(int64(seg.BagOffset), io.SeekStart)
 	if err != nil {
@@ -311,6 +312,17 @@ func (seg *Segment) open() (io.Reader, error) {
 	if err != nil {
 		return nil, err
 	}
+	return data, nil
+}
+
+func (seg *Segment) open() (io.Reader, error) {
+	if seg.data != nil {
+		return bytes.NewReader(seg.data), nil
+	}
+	data, err := seg.readData()
+	if err != nil {
+		return nil, err
+	}
 	seg.data = data
 	return bytes.NewReader(data), nil
 }
@@ -0,0 +1,221 @@
+package bag
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"sync"
+
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+)
+
+const (
+	// DefaultCacheSegments is the default memory limit for decompressed segments in Cache.
+	DefaultCacheSegments = 64 << 20
+	// DefaultCacheImages is the default memory limit for decoded images in Cache.
+	DefaultCacheImages = 128 << 20
+)
+
+// CacheOptions configures Cache.
+type CacheOptions struct {
+	// MaxSegmentBytes limits the memory used by decompressed segments. DefaultCacheSegments is used if not set.
+	// Negative value disables segment caching.
+	MaxSegmentBytes int64
+	// MaxImageBytes limits the memory used by decoded images. DefaultCacheImages is used if not set.
+	// Negative value disables image caching.
+	MaxImageBytes int64
+}
+
+// CacheStats contains statistics for Cache.
+type CacheStats struct {
+	ImageHits      uint64 `json:"image_hits"`
+	ImageMisses    uint64 `json:"image_misses"`
+	ImageEvicted   uint64 `json:"image_evicted"`
+	ImageBytes     int64  `json:"image_bytes"`
+	SegmentHits    uint64 `json:"segment_hits"`
+	SegmentMisses  uint64 `json:"segment_misses"`
+	SegmentEvicted uint64 `json:"segment_evicted"`
+	SegmentBytes   int64  `json:"segment_bytes"`
+}
+
+// Cache is a size-bounded LRU cache of decompressed segments and decoded images of a File.
+//
+// Cache is safe for concurrent use. All access to the File must go through the Cache after it's created.
+type Cache struct {
+	f *File
+
+	fmu sync.Mutex // protects File access
+	mu  sync.Mutex // protects fields below
+	// Decoded images are shared between callers, thus they must not be modified.
+	imgs  lru[int, *pcx.Image]
+	segs  lru[int, []byte]
+	stats CacheStats
+}
+
+// NewCache creates a new cache for the File. It reads the index immediately.
+func NewCache(f *File, opts *CacheOptions) (*Cache, error) {
+	if err := f.ensureImages(); err != nil {
+		return nil, err
+	}
+	var o CacheOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxSegmentBytes == 0 {
+		o.MaxSegmentBytes = DefaultCacheSegments
+	}
+	if o.MaxImageBytes == 0 {
+		o.MaxImageBytes = DefaultCacheImages
+	}
+	c := &Cache{f: f}
+	c.imgs.init(o.MaxImageBytes)
+	c.segs.init(o.MaxSegmentBytes)
+	return c, nil
+}
+
+// Stats returns cache statistics.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.stats
+	st.ImageBytes = c.imgs.size
+	st.SegmentBytes = c.segs.size
+	return st
+}
+
+// Image returns an image record by index. It returns nil if the index is out of range.
+func (c *Cache) Image(ind int) *ImageRec {
+	// images are read when cache is created, and not modified after
+	if ind < 0 || ind >= len(c.f.imgs) {
+		return nil
+	}
+	return c.f.imgs[ind]
+}
+
+func (c *Cache) segment(seg *Segment) ([]byte, error) {
+	c.mu.Lock()
+	data, ok := c.segs.get(seg.Index)
+	if ok {
+		c.stats.SegmentHits++
+	} else {
+		c.stats.SegmentMisses++
+	}
+	c.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+	c.fmu.Lock()
+	data, err := seg.readData()
+	c.fmu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.stats.SegmentEvicted += c.segs.add(seg.Index, data, int64(len(data)))
+	c.mu.Unlock()
+	return data, nil
+}
+
+// Raw returns raw image data. Returned slice must not be modified.
+func (c *Cache) Raw(img *ImageRec) ([]byte, error) {
+	data, err := c.segment(img.s)
+	if err != nil {
+		return nil, err
+	}
+	end := int(img.Offset) + int(img.Size)
+	if end > len(data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data[img.Offset:end:end], nil
+}
+
+// Decode the image or return a cached copy. Returned image is shared and must not be modified.
+func (c *Cache) Decode(img *ImageRec) (*pcx.Image, error) {
+	c.mu.Lock()
+	pimg, ok := c.imgs.get(img.Index)
+	if ok {
+		c.stats.ImageHits++
+	} else {
+		c.stats.ImageMisses++
+	}
+	c.mu.Unlock()
+	if ok {
+		return pimg, nil
+	}
+	data, err := c.Raw(img)
+	if err != nil {
+		return nil, err
+	}
+	pimg, err = pcx.Decode(bytes.NewReader(data), byte(img.Type))
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.stats.ImageEvicted += c.imgs.add(img.Index, pimg, imageMemSize(pimg))
+	c.mu.Unlock()
+	return pimg, nil
+}
+
+// imageMemSize estimates memory used by the decoded image.
+func imageMemSize(img *pcx.Image) int64 {
+	sz := img.Bounds().Size()
+	n := int64(sz.X) * int64(sz.Y) * 4
+	if img.Material != nil {
+		n += int64(sz.X) * int64(sz.Y)
+	}
+	return n
+}
+
+// lru is a size-bounded cache with least-recently-used eviction policy. It's not safe for concurrent use.
+type lru[K comparable, V any] struct {
+	max  int64
+	size int64
+	ll   *list.List
+	m    map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key  K
+	val  V
+	size int64
+}
+
+func (c *lru[K, V]) init(max int64) {
+	c.max = max
+	c.ll = list.New()
+	c.m = make(map[K]*list.Element)
+}
+
+func (c *lru[K, V]) get(key K) (V, bool) {
+	e, ok := c.m[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*lruEntry[K, V]).val, true
+}
+
+// add a value to the cache and return the number of evicted entries.
+func (c *lru[K, V]) add(key K, val V, size int64) uint64 {
+	if size > c.max {
+		return 0 // also handles disabled cache
+	}
+	if e, ok := c.m[key]; ok {
+		// added concurrently
+		c.ll.MoveToFront(e)
+		return 0
+	}
+	c.m[key] = c.ll.PushFront(&lruEntry[K, V]{key: key, val: val, size: size})
+	c.size += size
+	var n uint64
+	for c.size > c.max {
+		e := c.ll.Back()
+		ent := e.Value.(*lruEntry[K, V])
+		c.ll.Remove(e)
+		delete(c.m, ent.key)
+		c.size -= ent.size
+		n++
+	}
+	return n
+}
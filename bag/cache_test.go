@@ -0,0 +1,83 @@
+package bag
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestCache(t *testing.T) {
+	path, segs := writeTestBag(t)
+	f, err := Open(path)
+	must.NoError(t, err)
+	defer f.Close()
+
+	c, err := NewCache(f, nil)
+	must.NoError(t, err)
+	var exp []SegmentImage
+	for _, imgs := range segs {
+		exp = append(exp, imgs...)
+	}
+
+	// must cannot be used outside of the test goroutine, thus errors are collected and checked later
+	const workers = 8
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range exp {
+				rec := c.Image(j)
+				data, err := c.Raw(rec)
+				if err != nil {
+					errs[i] = err
+					return
+				} else if !bytes.Equal(exp[j].Data, data) {
+					errs[i] = fmt.Errorf("image %d: unexpected data", j)
+					return
+				}
+				img, err := c.Decode(rec)
+				if err != nil {
+					errs[i] = err
+					return
+				} else if img == nil {
+					errs[i] = fmt.Errorf("image %d: nil image", j)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		must.NoError(t, err)
+	}
+	must.Nil(t, c.Image(len(exp)))
+
+	st := c.Stats()
+	must.EqOp(t, uint64(workers*len(exp)), st.ImageHits+st.ImageMisses)
+	must.Positive(t, st.ImageHits)
+	must.Positive(t, st.SegmentHits)
+	must.EqOp(t, uint64(0), st.ImageEvicted)
+	must.Positive(t, st.ImageBytes)
+	must.Positive(t, st.SegmentBytes)
+
+	// small cache that fits only one image
+	c, err = NewCache(f, &CacheOptions{MaxImageBytes: 4 * 6 * 6, MaxSegmentBytes: -1})
+	must.NoError(t, err)
+	for j := range exp {
+		_, err = c.Decode(c.Image(j))
+		must.NoError(t, err)
+	}
+	_, err = c.Decode(c.Image(0))
+	must.NoError(t, err)
+	st = c.Stats()
+	must.EqOp(t, uint64(0), st.ImageHits)
+	must.EqOp(t, uint64(len(exp)+1), st.ImageMisses)
+	must.Positive(t, st.ImageEvicted)
+	must.LessEq(t, int64(4*6*6), st.ImageBytes)
+	must.EqOp(t, int64(0), st.SegmentBytes)
+}
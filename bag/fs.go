@@ -0,0 +1,270 @@
+package bag
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	_ fs.ReadDirFS = (*imageFS)(nil)
+	_ fs.StatFS    = (*imageFS)(nil)
+)
+
+// FS returns a read-only file system view of video.bag images.
+//
+// Each segment is represented as a directory with a zero-padded segment index as a name (e.g. "00012").
+// Images in the segment are exposed as PNG files, named after the image (e.g. "00012/wall.png").
+// If the raw flag is set, images are exposed as raw encoded data instead, with the original image name (e.g. "00012/wall.pcx").
+// If names collide inside one segment, the image index is added to the file name (e.g. "wall_123.png").
+//
+// Images are decoded lazily when the file is opened and are cached, see Cache.
+// Since the size of PNG files is only known after encoding, Stat and ReadDir report it as -1 for PNG images.
+// The file system is safe for concurrent use, but the File must not be used directly while it's in use.
+func (f *File) FS(raw bool) (fs.FS, error) {
+	segs, err := f.Segments()
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewCache(f, nil)
+	if err != nil {
+		return nil, err
+	}
+	fsys := &imageFS{
+		c:    c,
+		raw:  raw,
+		dirs: make(map[string]*fsDir, len(segs)),
+	}
+	fsys.root.name = "."
+	for _, seg := range segs {
+		d := &fsDir{name: fmt.Sprintf("%05d", seg.Index), files: make(map[string]*ImageRec, len(seg.Images))}
+		for _, img := range seg.Images {
+			name := fsys.fileName(img)
+			if _, ok := d.files[name]; ok {
+				ext := path.Ext(name)
+				name = strings.TrimSuffix(name, ext) + "_" + strconv.Itoa(img.Index) + ext
+			}
+			d.files[name] = img
+			d.names = append(d.names, name)
+		}
+		sort.Strings(d.names)
+		fsys.dirs[d.name] = d
+		fsys.root.names = append(fsys.root.names, d.name)
+	}
+	sort.Strings(fsys.root.names)
+	return fsys, nil
+}
+
+type imageFS struct {
+	c    *Cache
+	raw  bool
+	root fsDir
+	dirs map[string]*fsDir
+}
+
+type fsDir struct {
+	name  string
+	names []string
+	files map[string]*ImageRec
+}
+
+func (fsys *imageFS) fileName(img *ImageRec) string {
+	name := img.Name
+	if name == "" {
+		name = strconv.Itoa(img.Index)
+	}
+	name = strings.ReplaceAll(name, "/", "_")
+	if fsys.raw {
+		return name
+	}
+	return strings.TrimSuffix(name, path.Ext(name)) + ".png"
+}
+
+func (fsys *imageFS) lookup(op, name string) (*fsDir, *ImageRec, error) {
+	if !fs.ValidPath(name) {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &fsys.root, nil, nil
+	}
+	dname, fname, _ := strings.Cut(name, "/")
+	d := fsys.dirs[dname]
+	if d == nil {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	if fname == "" {
+		return d, nil, nil
+	}
+	img := d.files[fname]
+	if img == nil {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return nil, img, nil
+}
+
+// readImage returns file data for the image.
+func (fsys *imageFS) readImage(img *ImageRec) ([]byte, error) {
+	if fsys.raw {
+		return fsys.c.Raw(img)
+	}
+	pimg, err := fsys.c.Decode(img)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, pimg.Image); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (fsys *imageFS) Open(name string) (fs.File, error) {
+	d, img, err := fsys.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if d != nil {
+		return &fsDirFile{fsys: fsys, d: d}, nil
+	}
+	data, err := fsys.readImage(img)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &fsFile{
+		Reader: bytes.NewReader(data),
+		info:   fsFileInfo{name: path.Base(name), size: int64(len(data))},
+	}, nil
+}
+
+func (fsys *imageFS) Stat(name string) (fs.FileInfo, error) {
+	d, img, err := fsys.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	if d != nil {
+		return fsFileInfo{name: d.name, dir: true}, nil
+	}
+	return fsys.fileInfo(path.Base(name), img), nil
+}
+
+// fileInfo returns file info for the image without decoding it.
+// Size of PNG images is reported as -1, since it's only known after encoding. Opened files report the actual size.
+func (fsys *imageFS) fileInfo(name string, img *ImageRec) fsFileInfo {
+	if fsys.raw {
+		return fsFileInfo{name: name, size: int64(img.Size)}
+	}
+	// size of PNG is only known after encoding
+	return fsFileInfo{name: name, size: -1}
+}
+
+func (fsys *imageFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	d, _, err := fsys.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	} else if d == nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	return fsys.entries(d), nil
+}
+
+func (fsys *imageFS) entries(d *fsDir) []fs.DirEntry {
+	out := make([]fs.DirEntry, 0, len(d.names))
+	for _, name := range d.names {
+		// files map is not set for the root, thus all entries will be directories
+		out = append(out, fsDirEntry{fsys: fsys, name: name, img: d.files[name]})
+	}
+	return out
+}
+
+type fsFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi fsFileInfo) Name() string       { return fi.name }
+func (fi fsFileInfo) Size() int64        { return fi.size }
+func (fi fsFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fsFileInfo) IsDir() bool        { return fi.dir }
+func (fi fsFileInfo) Sys() any           { return nil }
+
+func (fi fsFileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+type fsDirEntry struct {
+	fsys *imageFS
+	name string
+	img  *ImageRec // nil for directories
+}
+
+func (e fsDirEntry) Name() string { return e.name }
+func (e fsDirEntry) IsDir() bool  { return e.img == nil }
+
+func (e fsDirEntry) Type() fs.FileMode {
+	if e.img == nil {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+func (e fsDirEntry) Info() (fs.FileInfo, error) {
+	if e.img == nil {
+		return fsFileInfo{name: e.name, dir: true}, nil
+	}
+	return e.fsys.fileInfo(e.name, e.img), nil
+}
+
+type fsFile struct {
+	*bytes.Reader
+	info fsFileInfo
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *fsFile) Close() error               { return nil }
+
+type fsDirFile struct {
+	fsys *imageFS
+	d    *fsDir
+	ents []fs.DirEntry
+	off  int
+}
+
+func (f *fsDirFile) Stat() (fs.FileInfo, error) {
+	return fsFileInfo{name: f.d.name, dir: true}, nil
+}
+
+func (f *fsDirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.d.name, Err: fs.ErrInvalid}
+}
+
+func (f *fsDirFile) Close() error { return nil }
+
+func (f *fsDirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f.ents == nil {
+		f.ents = f.fsys.entries(f.d)
+	}
+	rest := f.ents[f.off:]
+	if n <= 0 {
+		f.off = len(f.ents)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	f.off += n
+	return rest[:n], nil
+}
@@ -0,0 +1,109 @@
+package bag
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/bag/internal/bagtest"
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+)
+
+func writeTestBag(t testing.TB) (string, [][]SegmentImage) {
+	segs := [][]SegmentImage{
+		{
+			{Name: "red.pcx", Type: 3, Data: pcx.Encode(bagtest.Sprite(4, 3, color.NRGBA{R: 255, A: 255}, image.Pt(1, 2)))},
+			{Name: "green.pcx", Type: 3, Data: pcx.Encode(bagtest.Sprite(2, 5, color.NRGBA{G: 255, A: 255}, image.Pt(0, 0)))},
+			{Name: "red.pcx", Type: 3, Data: pcx.Encode(bagtest.Sprite(1, 1, color.NRGBA{R: 255, A: 255}, image.Pt(0, 0)))},
+		},
+		{
+			{Name: "blue.pcx", Type: 3, Data: pcx.Encode(bagtest.Sprite(6, 6, color.NRGBA{B: 255, A: 255}, image.Pt(0, 0)))},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "video.bag")
+	w, err := Create(path, "")
+	must.NoError(t, err)
+	for _, imgs := range segs {
+		err = w.WriteSegment(imgs)
+		must.NoError(t, err)
+	}
+	err = w.Close()
+	must.NoError(t, err)
+	return path, segs
+}
+
+func TestFS(t *testing.T) {
+	path, segs := writeTestBag(t)
+	f, err := Open(path)
+	must.NoError(t, err)
+	defer f.Close()
+
+	fsys, err := f.FS(false)
+	must.NoError(t, err)
+
+	// walking and stat must not decode images
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err == nil && !d.IsDir() {
+			must.EqOp(t, -1, info.Size())
+		}
+		return err
+	})
+	must.NoError(t, err)
+	info, err := fs.Stat(fsys, "00000/green.png")
+	must.NoError(t, err)
+	must.EqOp(t, -1, info.Size())
+	must.EqOp(t, 0, fsys.(*imageFS).c.Stats().ImageMisses)
+
+	// fstest.TestFS cannot be used for PNG files, since sizes reported by Stat and opened files differ
+	ents, err := fs.ReadDir(fsys, "00000")
+	must.NoError(t, err)
+	var names []string
+	for _, e := range ents {
+		names = append(names, e.Name())
+	}
+	must.Eq(t, []string{"green.png", "red.png", "red_2.png"}, names)
+
+	rf, err := fsys.Open("00000/green.png")
+	must.NoError(t, err)
+	data, err := io.ReadAll(rf)
+	must.NoError(t, err)
+	info, err = rf.Stat()
+	must.NoError(t, err)
+	_ = rf.Close()
+	// opened files report the actual size
+	must.EqOp(t, int64(len(data)), info.Size())
+	img, err := png.Decode(bytes.NewReader(data))
+	must.NoError(t, err)
+	must.EqOp(t, image.Pt(2, 5), img.Bounds().Size())
+
+	fsys, err = f.FS(true)
+	must.NoError(t, err)
+	err = fstest.TestFS(fsys, "00000/red.pcx", "00000/red_2.pcx", "00000/green.pcx", "00001/blue.pcx")
+	must.NoError(t, err)
+
+	data, err = fs.ReadFile(fsys, "00001/blue.pcx")
+	must.NoError(t, err)
+	must.Eq(t, segs[1][0].Data, data)
+
+	var files []string
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	})
+	must.NoError(t, err)
+	must.Eq(t, []string{"00000/green.pcx", "00000/red.pcx", "00000/red_2.pcx", "00001/blue.pcx"}, files)
+}
@@ -0,0 +1,15 @@
+// Package bagtest implements test helpers for video.bag packages.
+package bagtest
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+	"github.com/noxworld-dev/opennox-lib/noxtest"
+)
+
+// Sprite creates a sprite image of a given size, filled with a single color.
+func Sprite(w, h int, c color.NRGBA, pt image.Point) *pcx.Image {
+	return &pcx.Image{Image: noxtest.FillNRGBA(w, h, c), ImageMeta: pcx.ImageMeta{Type: 3, Point: pt}}
+}
@@ -0,0 +1,164 @@
+package bag
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+)
+
+// OverrideImage is an image returned by Overrides.
+type OverrideImage struct {
+	// Image is either a high-resolution replacement, or the original decoded image.
+	// ImageMeta is always copied from the original image, thus draw offsets must be multiplied by Scale.
+	// Material is only set for original images.
+	pcx.Image
+	// Scale is the ratio of the replacement image size to the original one. It is 1 for original images.
+	Scale float64
+	// Path to the replacement file. It is empty for original images.
+	Path string
+}
+
+// Overrides resolves video.bag images to high-resolution replacements from mod directories.
+//
+// Replacements are PNG files named after the original image, ignoring case and extension
+// (e.g. "WallStone1.png" for "wallstone1.pcx"). Subdirectories are searched as well.
+// If several images share the same name, only the first one is replaced, same as the one returned by ImageByName.
+// The scale is detected from the width of the replacement relative to the original image.
+//
+// Overrides is safe for concurrent use.
+type Overrides struct {
+	c      *Cache
+	byName map[string]*ImageRec // normalized name -> first image with that name
+	files  map[string]string    // normalized name -> replacement path
+
+	mu   sync.Mutex
+	imgs lru[int, *OverrideImage]
+}
+
+// NewOverrides creates an override layer for images in the Cache.
+// If multiple directories contain a replacement for the same image, the last one wins.
+func NewOverrides(c *Cache, dirs ...string) (*Overrides, error) {
+	o := &Overrides{
+		c:      c,
+		byName: make(map[string]*ImageRec),
+		files:  make(map[string]string),
+	}
+	// images are read when cache is created, and not modified after
+	for _, img := range c.f.imgs {
+		name := normalizeImageName(img.Name)
+		if _, ok := o.byName[name]; !ok {
+			o.byName[name] = img
+		}
+	}
+	for _, dir := range dirs {
+		if err := o.addDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	o.imgs.init(DefaultCacheImages)
+	return o, nil
+}
+
+func (o *Overrides) addDir(dir string) error {
+	seen := make(map[string]string)
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".png") {
+			return nil
+		}
+		name := normalizeImageName(d.Name())
+		if prev, ok := seen[name]; ok {
+			return fmt.Errorf("multiple replacements for image %q: %s and %s", name, prev, path)
+		}
+		seen[name] = path
+		o.files[name] = path
+		return nil
+	})
+}
+
+// Path returns a path to the replacement of the image with a given name, or an empty string if there's none.
+func (o *Overrides) Path(name string) string {
+	return o.files[normalizeImageName(name)]
+}
+
+// path returns a path to the replacement of a specific image, or an empty string if there's none.
+func (o *Overrides) path(img *ImageRec) string {
+	name := normalizeImageName(img.Name)
+	if o.byName[name] != img {
+		// replacements are resolved by name, thus only apply to the first image with that name
+		return ""
+	}
+	return o.files[name]
+}
+
+// ImageByName finds an image by name and returns its replacement, or the original image if there's none.
+// It returns nil if image doesn't exist in video.bag.
+func (o *Overrides) ImageByName(name string) (*OverrideImage, error) {
+	img := o.byName[normalizeImageName(name)]
+	if img == nil {
+		return nil, nil
+	}
+	return o.Image(img)
+}
+
+// Image returns a replacement for the image, or the original image if there's none.
+// Returned image is shared and must not be modified.
+func (o *Overrides) Image(img *ImageRec) (*OverrideImage, error) {
+	path := o.path(img)
+	if path == "" {
+		orig, err := o.c.Decode(img)
+		if err != nil {
+			return nil, err
+		}
+		return &OverrideImage{Image: *orig, Scale: 1}, nil
+	}
+	o.mu.Lock()
+	out, ok := o.imgs.get(img.Index)
+	o.mu.Unlock()
+	if ok {
+		return out, nil
+	}
+	// original image is only needed for metadata and scale of the replacement
+	orig, err := o.c.Decode(img)
+	if err != nil {
+		return nil, err
+	}
+	hd, err := decodePNG(path)
+	if err != nil {
+		return nil, err
+	}
+	osz, sz := orig.Bounds().Size(), hd.Bounds().Size()
+	if osz.X == 0 || sz.X == 0 {
+		return nil, fmt.Errorf("%s: empty image", path)
+	}
+	out = &OverrideImage{
+		Image: pcx.Image{Image: hd, ImageMeta: orig.ImageMeta},
+		Scale: float64(sz.X) / float64(osz.X),
+		Path:  path,
+	}
+	o.mu.Lock()
+	o.imgs.add(img.Index, out, int64(sz.X)*int64(sz.Y)*4)
+	o.mu.Unlock()
+	return out, nil
+}
+
+func decodePNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,82 @@
+package bag
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/bag/internal/bagtest"
+)
+
+func writeTestPNG(t testing.TB, path string, w, h int) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	must.NoError(t, err)
+	f, err := os.Create(path)
+	must.NoError(t, err)
+	defer f.Close()
+	err = png.Encode(f, bagtest.Sprite(w, h, color.NRGBA{R: 255, G: 255, A: 255}, image.Point{}).Image)
+	must.NoError(t, err)
+}
+
+func TestOverrides(t *testing.T) {
+	path, _ := writeTestBag(t)
+	f, err := Open(path)
+	must.NoError(t, err)
+	defer f.Close()
+	c, err := NewCache(f, nil)
+	must.NoError(t, err)
+
+	mod1, mod2 := t.TempDir(), t.TempDir()
+	writeTestPNG(t, filepath.Join(mod1, "RED.png"), 8, 6)
+	writeTestPNG(t, filepath.Join(mod1, "sub", "green.png"), 8, 20)
+	writeTestPNG(t, filepath.Join(mod2, "green.png"), 6, 15)
+
+	o, err := NewOverrides(c, mod1, mod2)
+	must.NoError(t, err)
+	must.EqOp(t, filepath.Join(mod1, "RED.png"), o.Path("red.pcx"))
+	must.EqOp(t, "", o.Path("blue.pcx"))
+
+	img, err := o.ImageByName("red.pcx")
+	must.NoError(t, err)
+	must.EqOp(t, 2.0, img.Scale)
+	must.EqOp(t, image.Pt(1, 2), img.Point)
+	must.EqOp(t, image.Pt(8, 6), img.Bounds().Size())
+
+	// cached replacement must not decode the original image again
+	st := c.Stats()
+	img2, err := o.ImageByName("red.pcx")
+	must.NoError(t, err)
+	must.EqOp(t, img, img2)
+	must.Eq(t, st, c.Stats())
+
+	// only the first image with a given name is replaced
+	img, err = o.Image(c.Image(2))
+	must.NoError(t, err)
+	must.EqOp(t, 1.0, img.Scale)
+	must.EqOp(t, "", img.Path)
+	must.EqOp(t, image.Pt(1, 1), img.Bounds().Size())
+
+	img, err = o.ImageByName("Green")
+	must.NoError(t, err)
+	must.EqOp(t, 3.0, img.Scale)
+	must.EqOp(t, filepath.Join(mod2, "green.png"), img.Path)
+
+	img, err = o.ImageByName("blue.pcx")
+	must.NoError(t, err)
+	must.EqOp(t, 1.0, img.Scale)
+	must.EqOp(t, "", img.Path)
+	must.EqOp(t, image.Pt(6, 6), img.Bounds().Size())
+
+	img, err = o.ImageByName("missing")
+	must.NoError(t, err)
+	must.Nil(t, img)
+
+	writeTestPNG(t, filepath.Join(mod1, "sub2", "red.png"), 8, 6)
+	_, err = NewOverrides(c, mod1)
+	must.Error(t, err)
+}
@@ -322,8 +322,8 @@ func (rb *replacer) ReplaceSprites(list []Replacement) error {
 					if err != nil {
 						return err
 					}
-					offsX := int(endiness.Uint32(phdr[8:]))
-					offsY := int(endiness.Uint32(phdr[12:]))
+					offsX := int(int32(endiness.Uint32(phdr[8:])))
+					offsY := int(int32(endiness.Uint32(phdr[12:])))
 					out.Point = image.Pt(offsX, offsY)
 					skip -= int64(len(phdr))
 				}
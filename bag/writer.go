@@ -0,0 +1,167 @@
+package bag
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	magicComp   = 0xFAEDBCEB
+	magicNoComp = 0xFAEDBCEA
+)
+
+// SegmentImage is an encoded image that can be written with Writer.
+type SegmentImage struct {
+	Name string
+	Type byte
+	// Data is an encoded image, as returned by ImageRec.Raw or pcx.Encode.
+	Data []byte
+}
+
+// Writer creates new video.bag and video.idx files.
+type Writer struct {
+	bag *bufio.Writer
+	idx io.Writer
+	c   []io.Closer
+
+	// Compress is an optional function used to compress segment data, usually nxz.Compress.
+	// If it's not set, or if it returns data that is not smaller than the input, the segment is stored uncompressed.
+	Compress func(data []byte) ([]byte, error)
+
+	ibuf    bytes.Buffer // index records, excluding the header
+	seg     bytes.Buffer
+	segCnt  int
+	maxSize int
+	err     error
+	closed  bool
+}
+
+// NewWriter creates a new writer for video.bag and video.idx.
+// Index is buffered in memory and written to idx only when Close is called.
+func NewWriter(bag, idx io.Writer) *Writer {
+	return &Writer{bag: bufio.NewWriter(bag), idx: idx}
+}
+
+// Create video.bag and video.idx files. If ipath is empty, it's derived from the bag path.
+func Create(path, ipath string) (*Writer, error) {
+	if ipath == "" {
+		ipath = strings.TrimSuffix(path, ".bag") + ".idx"
+	}
+	bag, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := os.Create(ipath)
+	if err != nil {
+		_ = bag.Close()
+		return nil, err
+	}
+	w := NewWriter(bag, idx)
+	w.c = []io.Closer{bag, idx}
+	return w, nil
+}
+
+// WriteSegment writes a new segment with a given images.
+func (w *Writer) WriteSegment(imgs []SegmentImage) error {
+	if len(imgs) == 0 {
+		return errors.New("segment must contain at least one image")
+	}
+	return w.writeSegment(imgs, len(imgs))
+}
+
+// WriteSingle writes a new segment with a single image. Such segments are marked with -1 image count in the index.
+func (w *Writer) WriteSingle(img SegmentImage) error {
+	return w.writeSegment([]SegmentImage{img}, -1)
+}
+
+func (w *Writer) writeSegment(imgs []SegmentImage, cnt int) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.seg.Reset()
+	var rec bytes.Buffer
+	for _, img := range imgs {
+		name := img.Name + "\x00"
+		if len(name) > 0xff {
+			w.err = fmt.Errorf("image name is too long: %q", img.Name)
+			return w.err
+		}
+		rec.WriteByte(byte(len(name)))
+		rec.WriteString(name)
+		rec.WriteByte(img.Type)
+		var b [8]byte
+		endiness.PutUint32(b[0:], uint32(len(img.Data))) // 16 bit image size
+		endiness.PutUint32(b[4:], uint32(len(img.Data))) // 8 bit image size, we don't generate 8 bit bags
+		rec.Write(b[:])
+		w.seg.Write(img.Data)
+	}
+	data := w.seg.Bytes()
+	size := len(data)
+	if w.Compress != nil {
+		cdata, err := w.Compress(data)
+		if err != nil {
+			w.err = fmt.Errorf("cannot compress segment %d: %w", w.segCnt, err)
+			return w.err
+		}
+		if len(cdata) < len(data) {
+			data = cdata
+		}
+	}
+	if _, err := w.bag.Write(data); err != nil {
+		w.err = err
+		return err
+	}
+	var hdr [16]byte
+	endiness.PutUint32(hdr[0:], uint32(rec.Len())) // size of image records
+	endiness.PutUint32(hdr[4:], uint32(size))
+	endiness.PutUint32(hdr[8:], uint32(len(data)))
+	endiness.PutUint32(hdr[12:], uint32(int32(cnt)))
+	w.ibuf.Write(hdr[:])
+	w.ibuf.Write(rec.Bytes())
+	w.segCnt++
+	if size > w.maxSize {
+		w.maxSize = size
+	}
+	return nil
+}
+
+// Close flushes video.bag data and writes the video.idx file. Calling Close more than once has no effect.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	err := w.close()
+	for _, c := range w.c {
+		if err2 := c.Close(); err == nil {
+			err = err2
+		}
+	}
+	w.c = nil
+	return err
+}
+
+func (w *Writer) close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.bag.Flush(); err != nil {
+		return err
+	}
+	var hdr [24]byte
+	endiness.PutUint32(hdr[0:], magicComp)
+	endiness.PutUint32(hdr[4:], uint32(len(hdr)+w.ibuf.Len()))
+	endiness.PutUint32(hdr[8:], uint32(w.segCnt))
+	endiness.PutUint32(hdr[12:], uint32(w.maxSize)) // largest segment, used to allocate buffers
+	// the rest of the header is unknown and left zero
+	if _, err := w.idx.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.idx.Write(w.ibuf.Bytes())
+	return err
+}
@@ -0,0 +1,107 @@
+package bag
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/bag/internal/bagtest"
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+	"github.com/noxworld-dev/opennox-lib/nxz"
+)
+
+func TestWriter(t *testing.T) {
+	sprites := []*pcx.Image{
+		bagtest.Sprite(4, 3, color.NRGBA{R: 255, A: 255}, image.Pt(1, 2)),
+		bagtest.Sprite(2, 5, color.NRGBA{G: 255, A: 255}, image.Pt(-3, 4)),
+		bagtest.Sprite(6, 6, color.NRGBA{B: 255, A: 255}, image.Pt(0, 0)),
+	}
+	var imgs []SegmentImage
+	for i, img := range sprites {
+		imgs = append(imgs, SegmentImage{
+			Name: []string{"red.pcx", "green.pcx", "blue.pcx"}[i],
+			Type: img.Type,
+			Data: pcx.Encode(img),
+		})
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "video.bag")
+	w, err := Create(path, "")
+	must.NoError(t, err)
+	err = w.WriteSegment(imgs[:2])
+	must.NoError(t, err)
+	err = w.WriteSingle(imgs[2])
+	must.NoError(t, err)
+	err = w.Close()
+	must.NoError(t, err)
+
+	f, err := Open(path)
+	must.NoError(t, err)
+	defer f.Close()
+	segs, err := f.Segments()
+	must.NoError(t, err)
+	must.Len(t, 2, segs)
+	must.EqOp(t, 2, segs[0].ImagesCnt)
+	must.EqOp(t, -1, segs[1].ImagesCnt)
+
+	list, err := f.Images()
+	must.NoError(t, err)
+	must.Len(t, len(imgs), list)
+	for i, rec := range list {
+		must.EqOp(t, i, rec.Index)
+		must.EqOp(t, imgs[i].Name, rec.Name)
+		must.EqOp(t, uint16(imgs[i].Type), rec.Type)
+		data, err := rec.Raw()
+		must.NoError(t, err)
+		must.Eq(t, imgs[i].Data, data)
+		img, err := rec.Decode()
+		must.NoError(t, err)
+		must.Eq(t, sprites[i].ImageMeta, img.ImageMeta)
+		must.EqOp(t, sprites[i].Bounds().Size(), img.Bounds().Size())
+	}
+}
+
+func TestWriterCloseTwice(t *testing.T) {
+	var bag, idx bytes.Buffer
+	w := NewWriter(&bag, &idx)
+	err := w.WriteSingle(SegmentImage{Name: "red.pcx", Type: 3, Data: pcx.Encode(bagtest.Sprite(2, 2, color.NRGBA{R: 255, A: 255}, image.Point{}))})
+	must.NoError(t, err)
+	err = w.Close()
+	must.NoError(t, err)
+	size := idx.Len()
+	err = w.Close()
+	must.NoError(t, err)
+	must.EqOp(t, size, idx.Len())
+}
+
+func TestWriterCompress(t *testing.T) {
+	sprite := bagtest.Sprite(64, 64, color.NRGBA{R: 255, A: 255}, image.Pt(1, 2))
+	img := SegmentImage{Name: "red.pcx", Type: sprite.Type, Data: pcx.Encode(sprite)}
+
+	path := filepath.Join(t.TempDir(), "video.bag")
+	w, err := Create(path, "")
+	must.NoError(t, err)
+	w.Compress = nxz.Compress
+	err = w.WriteSegment([]SegmentImage{img, img})
+	must.NoError(t, err)
+	err = w.Close()
+	must.NoError(t, err)
+
+	f, err := Open(path)
+	must.NoError(t, err)
+	defer f.Close()
+	segs, err := f.Segments()
+	must.NoError(t, err)
+	must.Len(t, 1, segs)
+	must.Less(t, segs[0].Size, segs[0].SizeComp)
+	for _, rec := range segs[0].Images {
+		data, err := rec.Raw()
+		must.NoError(t, err)
+		must.Eq(t, img.Data, data)
+	}
+}
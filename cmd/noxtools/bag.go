@@ -26,7 +26,10 @@ import (
 	"golang.org/x/exp/slices"
 
 	"github.com/noxworld-dev/opennox-lib/bag"
+	"github.com/noxworld-dev/opennox-lib/bag/atlas"
+	"github.com/noxworld-dev/opennox-lib/noximage"
 	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+	"github.com/noxworld-dev/opennox-lib/nxz"
 	"github.com/noxworld-dev/opennox-lib/things"
 )
 
@@ -183,6 +186,290 @@ func init() {
 			return bag.ReplaceSprites(*fBag, *fIdx, list)
 		}
 	}
+	cmdBuild := &cobra.Command{
+		Use:   "build [--bag video.bag] [--idx video.idx] [--meta video.idx.json] ./dir",
+		Short: "Builds new Nox video.bag and video.idx files from a directory",
+		Long: `Builds new Nox video.bag and video.idx files from a directory.
+
+Segment layout is taken from the metadata file generated by idx2json (video.idx.json in the directory by default).
+For each image, the directory must contain either a raw image data file (name.bin), or a PNG image (name.png)
+with an optional metadata (name.json) and material (name_mat.png) files, as generated by "extract --mode=imgs --json".`,
+	}
+	cmd.AddCommand(cmdBuild)
+	{
+		fMeta := cmdBuild.Flags().StringP("meta", "m", "", "path to JSON metadata generated by idx2json (default: dir/video.idx.json)")
+		fCompress := cmdBuild.Flags().BoolP("compress", "z", false, "compress segments with nxz")
+		cmdBuild.RunE = func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("expected one directory")
+			}
+			cmd.SilenceUsage = true
+			return bagBuild(args[0], *fMeta, *fBag, *fIdx, *fCompress)
+		}
+	}
+	cmdUpscale := &cobra.Command{
+		Use:   "upscale [--bag video.bag] [--idx video.idx] [--out ./out] [--scale 2] [--filter scalex] [file ...]",
+		Short: "Extracts upscaled images from Nox video.bag file",
+		Long: `Extracts upscaled images from Nox video.bag file.
+
+Output has the same layout as "extract --mode=imgs --json", thus it can be used to build a new video.bag with "build".
+Image metadata is always written, with draw offsets scaled along with images.
+When all images are extracted, segment layout is written to video.idx.json as well.
+
+Only sprites are upscaled. Tiles and other image types must keep their size, thus they are copied as raw data (name.bin).`,
+	}
+	cmd.AddCommand(cmdUpscale)
+	{
+		fOut := cmdUpscale.Flags().StringP("out", "o", "", "output path for images or archive")
+		fScale := cmdUpscale.Flags().IntP("scale", "s", 2, "integer scale factor")
+		fFilter := cmdUpscale.Flags().StringP("filter", "f", noximage.FilterScaleX.String(), "upscaling filter (nearest, scalex for EPX)")
+		cmdUpscale.RunE = func(cmd *cobra.Command, args []string) error {
+			filter, err := noximage.ParseFilter(*fFilter)
+			if err != nil {
+				return err
+			}
+			if *fScale < 1 {
+				return fmt.Errorf("invalid scale factor: %d", *fScale)
+			}
+			cmd.SilenceUsage = true
+			f, err := bag.OpenWithIndex(*fBag, *fIdx)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return bagUpscale(f, *fOut, *fScale, filter, args...)
+		}
+	}
+	cmdAtlas := &cobra.Command{
+		Use:   "atlas [--bag video.bag] [--idx video.idx] [--out ./out] [--thing name] [--range from-to] [sprite ...]",
+		Short: "Packs sprites from Nox video.bag file into texture atlases",
+	}
+	cmd.AddCommand(cmdAtlas)
+	{
+		fOut := cmdAtlas.Flags().StringP("out", "o", ".", "output directory")
+		fName := cmdAtlas.Flags().StringP("name", "n", "atlas", "base name for output files")
+		fThings := cmdAtlas.Flags().StringSliceP("thing", "t", nil, "include all sprites used by a given thing")
+		fRanges := cmdAtlas.Flags().StringSliceP("range", "r", nil, "include sprites in the index range (inclusive), in the format: from-to")
+		fMaxSize := cmdAtlas.Flags().Int("max-size", atlas.DefaultMaxSize, "maximal size of the atlas page")
+		fPadding := cmdAtlas.Flags().Int("padding", atlas.DefaultPadding, "padding between sprites (negative to disable)")
+		cmdAtlas.RunE = func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && len(*fThings) == 0 && len(*fRanges) == 0 {
+				return errors.New("no sprites selected")
+			}
+			cmd.SilenceUsage = true
+			f, err := bag.OpenWithIndex(*fBag, *fIdx)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			var imgs []*bag.ImageRec
+			if len(args) != 0 {
+				list, err := atlas.SelectNames(f, args...)
+				if err != nil {
+					return err
+				}
+				imgs = append(imgs, list...)
+			}
+			for _, s := range *fRanges {
+				sub := strings.SplitN(s, "-", 2)
+				if len(sub) != 2 {
+					return fmt.Errorf("ranges should be in the format: from-to; got: %q", s)
+				}
+				from, err := strconv.Atoi(sub[0])
+				if err != nil {
+					return err
+				}
+				to, err := strconv.Atoi(sub[1])
+				if err != nil {
+					return err
+				}
+				list, err := atlas.SelectRange(f, from, to)
+				if err != nil {
+					return err
+				}
+				imgs = append(imgs, list...)
+			}
+			if len(*fThings) != 0 {
+				fname := filepath.Join(filepath.Dir(*fBag), "thing.bin")
+				r, err := things.Open(fname)
+				if err != nil {
+					return err
+				}
+				d, err := r.ReadAll()
+				_ = r.Close()
+				if err != nil {
+					return err
+				}
+				for _, name := range *fThings {
+					th := d.ThingByName(name)
+					if th == nil {
+						return fmt.Errorf("cannot find thing: %q", name)
+					}
+					list, err := atlas.SelectThing(f, d, th)
+					if err != nil {
+						return err
+					}
+					imgs = append(imgs, list...)
+				}
+			}
+			b := atlas.NewBuilder(&atlas.Options{MaxSize: *fMaxSize, Padding: *fPadding})
+			for _, img := range imgs {
+				if err := b.AddImage(img); err != nil {
+					return err
+				}
+			}
+			a, err := b.Build()
+			if err != nil {
+				return err
+			}
+			return a.WriteFiles(*fOut, *fName)
+		}
+	}
+}
+
+// bagBuild builds video.bag and video.idx files from a directory. See "build" command.
+func bagBuild(dir, meta, bpath, ipath string, compress bool) error {
+	if meta == "" {
+		meta = filepath.Join(dir, "video.idx.json")
+	}
+	data, err := os.ReadFile(meta)
+	if err != nil {
+		return err
+	}
+	var segs []*bag.Segment
+	if err = json.Unmarshal(data, &segs); err != nil {
+		return err
+	}
+	w, err := bag.Create(bpath, ipath)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if compress {
+		w.Compress = nxz.Compress
+	}
+	seen := make(map[string]int)
+	for _, seg := range segs {
+		imgs := make([]bag.SegmentImage, 0, len(seg.Images))
+		for _, rec := range seg.Images {
+			// same naming as in the extractor
+			base := strings.TrimSuffix(rec.Name, path.Ext(rec.Name))
+			key := strings.ToLower(base)
+			if n := seen[key]; n > 0 {
+				base += "_" + strconv.Itoa(n)
+			}
+			seen[key]++
+			img, err := loadSegmentImage(filepath.Join(dir, base), rec)
+			if err != nil {
+				return fmt.Errorf("image %d (%s): %w", rec.Index, rec.Name, err)
+			}
+			imgs = append(imgs, img)
+		}
+		if seg.ImagesCnt == -1 && len(imgs) == 1 {
+			err = w.WriteSingle(imgs[0])
+		} else {
+			err = w.WriteSegment(imgs)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// bagUpscale extracts upscaled images from video.bag. See "upscale" command.
+func bagUpscale(f *bag.File, out string, scale int, filter noximage.Filter, names ...string) error {
+	e := newExtractor(out, f)
+	defer e.Close()
+	e.json = true
+	e.scale = scale
+	e.filter = filter
+	if ext := filepath.Ext(out); ext == ".zip" || ext == ".gz" {
+		if err := e.Compress(ext); err != nil {
+			return err
+		}
+	} else if out != "" {
+		if err := os.MkdirAll(out, 0755); err != nil {
+			return err
+		}
+	}
+	if err := e.ExtractImages(names...); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		segs, err := f.Segments()
+		if err != nil {
+			return err
+		}
+		e.buf.Reset()
+		enc := json.NewEncoder(&e.buf)
+		enc.SetIndent("", "\t")
+		if err = enc.Encode(segs); err != nil {
+			return err
+		}
+		if err = e.writeFile("video.idx.json", &e.buf, int64(e.buf.Len())); err != nil {
+			return err
+		}
+	}
+	return e.Close()
+}
+
+// isSpriteType checks if images of a given type can be encoded from PNG.
+func isSpriteType(typ byte) bool {
+	switch typ {
+	case 3, 4, 5, 6:
+		return true
+	}
+	return false
+}
+
+// loadSegmentImage loads raw or encodes PNG image for the video.bag. Base is the image path without the extension.
+func loadSegmentImage(base string, rec *bag.ImageRec) (bag.SegmentImage, error) {
+	out := bag.SegmentImage{Name: rec.Name, Type: byte(rec.Type)}
+	if data, err := os.ReadFile(base + ".bin"); err == nil {
+		out.Data = data
+		return out, nil
+	} else if !os.IsNotExist(err) {
+		return out, err
+	}
+	if !isSpriteType(out.Type) {
+		return out, fmt.Errorf("raw image data is required for type %d", out.Type)
+	}
+	img, err := decodeImageFile(base + ".png")
+	if err != nil {
+		return out, err
+	}
+	pimg := &pcx.Image{Image: img, ImageMeta: pcx.ImageMeta{Type: out.Type}}
+	if data, err := os.ReadFile(base + ".json"); err == nil {
+		if err = json.Unmarshal(data, &pimg.ImageMeta); err != nil {
+			return out, err
+		}
+		pimg.Type = out.Type
+	} else if !os.IsNotExist(err) {
+		return out, err
+	}
+	if mat, err := decodeImageFile(base + "_mat.png"); err == nil {
+		pal, ok := mat.(*image.Paletted)
+		if !ok {
+			return out, errors.New("material image must be paletted")
+		}
+		pimg.Material = pal
+	} else if !os.IsNotExist(err) {
+		return out, err
+	}
+	out.Data = pcx.Encode(pimg)
+	return out, nil
+}
+
+func decodeImageFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
 }
 
 func newExtractor(out string, b *bag.File) *extractor {
@@ -193,6 +480,8 @@ type extractor struct {
 	b       *bag.File
 	out     string
 	json    bool
+	scale   int // upscale images if greater than 1
+	filter  noximage.Filter
 	closers []func() error
 	zw      *zip.Writer
 	tw      *tar.Writer
@@ -318,10 +607,6 @@ func (e *extractor) ExtractImages(names ...string) error {
 }
 
 func (e *extractor) processImageRec(base string, img *bag.ImageRec) error {
-	im, err := img.Decode()
-	if err != nil {
-		return err
-	}
 	if base == "" {
 		base = strings.TrimSuffix(img.Name, path.Ext(img.Name))
 	}
@@ -330,6 +615,24 @@ func (e *extractor) processImageRec(base string, img *bag.ImageRec) error {
 		base += "_" + strconv.Itoa(n)
 	}
 	e.seen[key]++
+	if e.scale > 1 && !isSpriteType(byte(img.Type)) {
+		// tiles must keep their size, thus they are copied as-is; see loadSegmentImage
+		data, err := img.Raw()
+		if err != nil {
+			return err
+		}
+		return e.writeFile(base+".bin", bytes.NewReader(data), int64(len(data)))
+	}
+	im, err := img.Decode()
+	if err != nil {
+		return err
+	}
+	if e.scale > 1 {
+		im, err = pcx.Scale(im, e.scale, e.filter)
+		if err != nil {
+			return err
+		}
+	}
 	if e.json {
 		e.buf.Reset()
 		enc := json.NewEncoder(&e.buf)
@@ -362,6 +665,15 @@ func (e *extractor) processImageRec(base string, img *bag.ImageRec) error {
 	return nil
 }
 
+func (e *extractor) processVectorAnimation(name string, ani *things.VectorAnimation) error {
+	frames := make([]things.ImageRef, 0, len(ani.Frames)*int(ani.FramesPerDir))
+	for _, dir := range ani.Frames {
+		frames = append(frames, dir...)
+	}
+	_, err := e.processFrames(name, frames, int(ani.FramesPerDir))
+	return err
+}
+
 func (e *extractor) processFrames(name string, frames []things.ImageRef, row int) (image.Point, error) {
 	if row <= 0 {
 		row = len(frames)
@@ -650,6 +962,38 @@ func (e *extractor) ExtractObjects(thg *things.Reader, all bool, names ...string
 					continue
 				}
 			}
+		case things.AnimateStateDraw:
+			for _, v := range dr.Anims {
+				name := v.Name
+				if name == "" {
+					name = strconv.Itoa(int(v.State))
+				}
+				_, err := e.processFrames(fmt.Sprintf(pref+"%s/%s", th.Name, name), v.Anim.Frames, 0)
+				if err != nil {
+					last = err
+					log.Println(th.Name, last)
+					continue
+				}
+			}
+		case things.VectorAnimateDraw:
+			if err := e.processVectorAnimation(pref+th.Name, &dr.Anim); err != nil {
+				last = err
+				log.Println(th.Name, last)
+				continue
+			}
+		case things.ReleasedSoulDraw:
+			if err := e.processVectorAnimation(pref+th.Name, &dr.Anim); err != nil {
+				last = err
+				log.Println(th.Name, last)
+				continue
+			}
+		case things.DirectionalDraw:
+			_, err := e.processFrames(pref+th.Name, dr.Frames(), 0)
+			if err != nil {
+				last = err
+				log.Println(th.Name, last)
+				continue
+			}
 		case things.MonsterGeneratorDraw:
 			for i, v := range dr.Anims {
 				var name string
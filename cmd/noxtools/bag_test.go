@@ -0,0 +1,63 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/bag"
+	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
+	"github.com/noxworld-dev/opennox-lib/noxtest"
+)
+
+func runCommand(t testing.TB, args ...string) {
+	Root.SetArgs(args)
+	err := Root.Execute()
+	must.NoError(t, err)
+}
+
+func TestBagUpscaleBuild(t *testing.T) {
+	white := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	sprite := &pcx.Image{Image: noxtest.FillNRGBA(4, 3, white), ImageMeta: pcx.ImageMeta{Type: 3, Point: image.Pt(-2, 5)}}
+	tile := &pcx.Image{Image: noxtest.FillNRGBA(46, 46, white)}
+	imgs := []bag.SegmentImage{
+		{Name: "sprite.pcx", Type: 3, Data: pcx.Encode(sprite)},
+		{Name: "tile.pcx", Type: 0, Data: pcx.EncodeTileImage(tile)},
+	}
+
+	dir := t.TempDir()
+	bpath := filepath.Join(dir, "video.bag")
+	w, err := bag.Create(bpath, "")
+	must.NoError(t, err)
+	err = w.WriteSegment(imgs)
+	must.NoError(t, err)
+	err = w.Close()
+	must.NoError(t, err)
+
+	out := filepath.Join(dir, "out")
+	runCommand(t, "videobag", "upscale", "--bag", bpath, "--out", out, "--filter", "nearest")
+	bpath2 := filepath.Join(dir, "video2.bag")
+	runCommand(t, "videobag", "build", "--bag", bpath2, out)
+
+	f, err := bag.Open(bpath2)
+	must.NoError(t, err)
+	defer f.Close()
+	list, err := f.Images()
+	must.NoError(t, err)
+	must.Len(t, 2, list)
+
+	img, err := list[0].Decode()
+	must.NoError(t, err)
+	must.EqOp(t, image.Pt(8, 6), img.Bounds().Size())
+	must.EqOp(t, image.Pt(-4, 10), img.Point)
+	must.EqOp(t, color.NRGBA{R: 0xf8, G: 0xf8, B: 0xf8, A: 0xff}, color.NRGBAModel.Convert(img.At(7, 5)).(color.NRGBA))
+
+	// tiles are copied without scaling
+	must.EqOp(t, uint16(0), list[1].Type)
+	data, err := list[1].Raw()
+	must.NoError(t, err)
+	must.Eq(t, imgs[1].Data, data)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/image/font/opentype"
+
+	"github.com/noxworld-dev/opennox-lib/noxfont"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "font command",
+		Short: "Tools for working with Nox bitmap fonts (*.fnt)",
+	}
+	Root.AddCommand(cmd)
+
+	cmdBuild := &cobra.Command{
+		Use:   "build [--size 12] [--chars 20-7E,A0-FF] input.ttf [output.fnt]",
+		Short: "Builds Nox bitmap font from a TrueType or OpenType font",
+	}
+	cmd.AddCommand(cmdBuild)
+	fSize := cmdBuild.Flags().Float64P("size", "s", 12, "font size in pixels")
+	fChars := cmdBuild.Flags().StringP("chars", "c", "", "comma-separated hex character ranges (default: ASCII and Latin-1)")
+	fThresh := cmdBuild.Flags().Uint8("threshold", 128, "minimal pixel coverage (1-255)")
+	fInline := cmdBuild.Flags().Bool("inline", false, "use legacy inline font format")
+	cmdBuild.RunE = func(cmd *cobra.Command, args []string) error {
+		in, out, err := fontConvertArgs(args, noxfont.Ext)
+		if err != nil {
+			return err
+		}
+		chars, err := noxfont.ParseCharRanges(*fChars)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		data, err := os.ReadFile(in)
+		if err != nil {
+			return err
+		}
+		f, err := opentype.Parse(data)
+		if err != nil {
+			return err
+		}
+		fnt, err := noxfont.Rasterize(f, &noxfont.RasterOptions{
+			Size:      *fSize,
+			Chars:     chars,
+			Threshold: *fThresh,
+			Inline:    *fInline,
+		})
+		if err != nil {
+			return err
+		}
+		data, err = fnt.Encode()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(out, data, 0644)
+	}
+
+	cmdToBDF := &cobra.Command{
+		Use:   "tobdf input.fnt [output.bdf]",
+		Short: "Converts Nox bitmap font to BDF format",
+	}
+	cmd.AddCommand(cmdToBDF)
+	cmdToBDF.RunE = func(cmd *cobra.Command, args []string) error {
+		in, out, err := fontConvertArgs(args, ".bdf")
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		fnt, err := noxfont.Decode(f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+		w, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+		name := strings.TrimSuffix(filepath.Base(in), filepath.Ext(in))
+		if err = fnt.EncodeBDF(w, name); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+
+	cmdFromBDF := &cobra.Command{
+		Use:   "frombdf input.bdf [output.fnt]",
+		Short: "Converts BDF font to Nox bitmap font",
+	}
+	cmd.AddCommand(cmdFromBDF)
+	cmdFromBDF.RunE = func(cmd *cobra.Command, args []string) error {
+		in, out, err := fontConvertArgs(args, noxfont.Ext)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		fnt, err := noxfont.DecodeBDF(f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+		data, err := fnt.Encode()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(out, data, 0644)
+	}
+}
+
+// fontConvertArgs returns input and output paths. If output is not set, it's derived from input with a given extension.
+func fontConvertArgs(args []string, ext string) (in, out string, _ error) {
+	switch len(args) {
+	case 1:
+		in = args[0]
+		return in, strings.TrimSuffix(in, filepath.Ext(in)) + ext, nil
+	case 2:
+		return args[0], args[1], nil
+	}
+	return "", "", errors.New("expected one or two arguments")
+}
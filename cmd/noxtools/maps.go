@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,6 +14,7 @@ import (
 	"golang.org/x/exp/slices"
 
 	"github.com/noxworld-dev/opennox-lib/maps"
+	"github.com/noxworld-dev/opennox-lib/nxz"
 )
 
 func init() {
@@ -23,10 +27,10 @@ func init() {
 
 	cmdCompress := &cobra.Command{
 		Use:   "compress mapdir",
-		Short: "Compresses a Nox/OpenNox map to ZIP archive",
+		Short: "Compresses a Nox/OpenNox map to ZIP archive or NXZ file",
 	}
 	cmd.AddCommand(cmdCompress)
-	cmdCompressFormat := cmdCompress.Flags().StringP("format", "f", "zip", "format to use (only zip for now)")
+	cmdCompressFormat := cmdCompress.Flags().StringP("format", "f", "zip", "format to use (zip or nxz)")
 	cmdCompressOut := cmdCompress.Flags().StringP("out", "o", "", "output file name")
 	cmdCompress.RunE = func(cmd *cobra.Command, args []string) error {
 		if len(args) != 1 {
@@ -34,6 +38,129 @@ func init() {
 		}
 		return cmdMapCompress(cmd, args[0], *cmdCompressOut, *cmdCompressFormat)
 	}
+
+	cmdUpgrade := &cobra.Command{
+		Use:   "upgrade [--out dir] path ...",
+		Short: "Upgrades legacy Nox maps to the current map format",
+		Long:  "Upgrades legacy Nox maps to the current map format. Paths may point to map files or directories, which are scanned recursively.",
+	}
+	cmd.AddCommand(cmdUpgrade)
+	cmdUpgradeOut := cmdUpgrade.Flags().StringP("out", "o", "", "output directory (empty means overwrite in place)")
+	cmdUpgradeForce := cmdUpgrade.Flags().BoolP("force", "f", false, "upgrade sections of maps that already use the current format")
+	cmdUpgradeVerbose := cmdUpgrade.Flags().BoolP("verbose", "v", false, "print skipped maps")
+	cmdUpgrade.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("at least one path expected")
+		}
+		cmd.SilenceUsage = true
+		return cmdMapUpgrade(args, *cmdUpgradeOut, *cmdUpgradeForce, *cmdUpgradeVerbose)
+	}
+}
+
+type mapUpgradeJob struct {
+	in  string
+	rel string // path relative to the output directory
+}
+
+func cmdMapUpgrade(paths []string, out string, force, verbose bool) error {
+	var files []mapUpgradeJob
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			// keep the map directory, if the file follows the standard layout: name/name.map
+			rel := filepath.Base(path)
+			if dir := filepath.Base(filepath.Dir(path)); strings.EqualFold(dir, strings.TrimSuffix(rel, filepath.Ext(rel))) {
+				rel = filepath.Join(dir, rel)
+			}
+			files = append(files, mapUpgradeJob{in: path, rel: rel})
+			continue
+		}
+		root := path
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(path), maps.Ext) {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				files = append(files, mapUpgradeJob{in: path, rel: rel})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	var last error
+	for _, job := range files {
+		in, dst := job.in, job.in
+		if out != "" {
+			dst = filepath.Join(out, job.rel)
+		}
+		upd, err := mapUpgradeFile(in, dst, force)
+		if err != nil {
+			log.Printf("%s: %v", in, err)
+			last = err
+			continue
+		}
+		if upd {
+			fmt.Println("upgraded:", in)
+		} else if verbose && out != "" {
+			fmt.Println("copied:", in)
+		} else if verbose {
+			fmt.Println("skipped:", in)
+		}
+	}
+	return last
+}
+
+// mapUpgradeFile upgrades a single map file. Maps that already use the current format are copied as-is,
+// unless the output path is the same as the input.
+func mapUpgradeFile(in, out string, force bool) (bool, error) {
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return false, err
+	}
+	rd, err := maps.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	upgrade := force || rd.Header().Magic != maps.Magic
+	if !upgrade && filepath.Clean(in) == filepath.Clean(out) {
+		return false, nil
+	}
+	if err = os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return false, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(out), filepath.Base(out)+".*.tmp")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err = f.Chmod(0644); err != nil {
+		return false, err
+	}
+	if upgrade {
+		err = maps.Upgrade(f, bytes.NewReader(data))
+	} else {
+		_, err = f.Write(data)
+	}
+	if err != nil {
+		return false, err
+	}
+	if err = f.Close(); err != nil {
+		return false, err
+	}
+	if err = os.Rename(f.Name(), out); err != nil {
+		return false, err
+	}
+	return upgrade, nil
 }
 
 func cmdMapCompress(cmd *cobra.Command, in, out string, format string) error {
@@ -56,8 +183,11 @@ func cmdMapCompress(cmd *cobra.Command, in, out string, format string) error {
 		if isDir {
 			in = filepath.Join(in, filepath.Base(in)+maps.Ext)
 		}
-		// FIXME: support NXZ encoding
-		return fmt.Errorf("NXZ encoding is not supported yet")
+		data, err := os.ReadFile(in)
+		if err != nil {
+			return err
+		}
+		return nxz.WriteFile(out, data)
 	case "zip":
 		if !isDir {
 			in = filepath.Dir(in)
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/noxworld-dev/opennox-lib/nxz"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "nxz command",
+		Short: "Tools for compressing and decompressing Nox nxz files (*.nxz)",
+	}
+	Root.AddCommand(cmd)
+
+	cmdComp := &cobra.Command{
+		Use:     "compress input [output]",
+		Short:   "Compresses a file (usually a map) to Nox nxz format",
+		Aliases: []string{"c"},
+	}
+	cmd.AddCommand(cmdComp)
+	cmdComp.RunE = func(cmd *cobra.Command, args []string) error {
+		var in, out string
+		switch len(args) {
+		case 1:
+			in = args[0]
+			out = strings.TrimSuffix(in, filepath.Ext(in)) + ".nxz"
+		case 2:
+			in, out = args[0], args[1]
+		default:
+			return errors.New("expected one or two arguments")
+		}
+		data, err := os.ReadFile(in)
+		if err != nil {
+			return err
+		}
+		return nxz.WriteFile(out, data)
+	}
+
+	cmdDecomp := &cobra.Command{
+		Use:     "decompress input [output]",
+		Short:   "Decompresses Nox nxz file",
+		Aliases: []string{"d"},
+	}
+	cmd.AddCommand(cmdDecomp)
+	cmdDecomp.RunE = func(cmd *cobra.Command, args []string) error {
+		var in, out string
+		switch len(args) {
+		case 1:
+			in = args[0]
+			out = strings.TrimSuffix(in, filepath.Ext(in)) + ".map"
+		case 2:
+			in, out = args[0], args[1]
+		default:
+			return errors.New("expected one or two arguments")
+		}
+		data, err := nxz.ReadFile(in)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(out, data, 0644)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"path/filepath"
 	"strings"
 
@@ -48,4 +50,178 @@ func init() {
 		}
 		return sm.WriteJSON(out, *fPretty)
 	}
+
+	cmdCSF := &cobra.Command{
+		Use:     "json2csf input output",
+		Short:   "Converts JSON strings to Nox string files (*.csf or *.str)",
+		Aliases: []string{"j2c"},
+	}
+	cmd.AddCommand(cmdCSF)
+	cmdCSF.RunE = func(cmd *cobra.Command, args []string) error {
+		var (
+			in  string
+			out string
+		)
+		if len(args) == 1 {
+			in = args[0]
+			out = strings.TrimSuffix(in, filepath.Ext(in))
+			if filepath.Ext(out) != ".csf" {
+				out += ".csf"
+			}
+		} else if len(args) == 2 {
+			in = args[0]
+			out = args[1]
+		} else {
+			return errors.New("expected one or two arguments")
+		}
+		sm := strman.New()
+		err := sm.ReadJSON(in)
+		if err != nil {
+			return err
+		}
+		return writeStrings(sm, out)
+	}
+
+	cmdToPO := &cobra.Command{
+		Use:   "topo [--tr translation.csf] source.csf output.po",
+		Short: "Exports Nox string files to gettext PO (or POT) files for translation",
+		Long: `Exports Nox string files to gettext PO (or POT) files for translation.
+
+Source and translation can be in CSF, STR or JSON format. If translation is not set, a POT template is written.`,
+	}
+	cmd.AddCommand(cmdToPO)
+	fTr := cmdToPO.Flags().StringP("tr", "t", "", "translation file to fill msgstr from")
+	cmdToPO.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("expected two arguments")
+		}
+		cmd.SilenceUsage = true
+		src, err := readStrings(args[0])
+		if err != nil {
+			return err
+		}
+		var tr *strman.StringManager
+		if *fTr != "" {
+			if tr, err = readStrings(*fTr); err != nil {
+				return err
+			}
+		}
+		return src.WritePO(args[1], tr)
+	}
+
+	cmdFromPO := &cobra.Command{
+		Use:   "frompo [--src source.csf] [--cp1251] input.po output.csf",
+		Short: "Imports translations from gettext PO files to Nox string files",
+		Long: `Imports translations from gettext PO files to Nox string files.
+
+Output format is selected by the extension: CSF, STR or JSON. Untranslated strings fall back to the source language,
+and if the source file is set, strings missing from the PO file are copied from it.
+CSF files are written in UTF-16, unless --cp1251 is set (required by the Russian version of Nox).`,
+	}
+	cmd.AddCommand(cmdFromPO)
+	fSrc := cmdFromPO.Flags().StringP("src", "s", "", "source language file (CSF, STR or JSON)")
+	fCP1251 := cmdFromPO.Flags().Bool("cp1251", false, "write CSF in CP-1251 encoding instead of UTF-16")
+	cmdFromPO.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("expected two arguments")
+		}
+		cmd.SilenceUsage = true
+		var src *strman.StringManager
+		if *fSrc != "" {
+			var err error
+			if src, err = readStrings(*fSrc); err != nil {
+				return err
+			}
+		}
+		sm := strman.New()
+		if err := sm.ReadPO(args[0], src); err != nil {
+			return err
+		}
+		sm.SetCP1251(*fCP1251)
+		return writeStrings(sm, args[1])
+	}
+
+	cmdCheck := &cobra.Command{
+		Use:   "check [--json] [--max-line N] reference.csf translation.csf",
+		Short: "Checks translation for format string and layout issues",
+		Long: `Checks translation for format string and layout issues.
+
+Reports mismatched printf-style format verbs and argument counts, line breaks, missing or extra entries and long lines.
+Files can be in CSF, STR, JSON or PO format. Exits with an error if any issues are found.`,
+	}
+	cmd.AddCommand(cmdCheck)
+	fCheckJSON := cmdCheck.Flags().Bool("json", false, "print issues as JSON")
+	fMaxLine := cmdCheck.Flags().Int("max-line", 0, "maximal line length in characters (0 means no limit)")
+	fLineRatio := cmdCheck.Flags().Float64("line-ratio", 0, "maximal line length relative to the longest reference line (0 means no limit)")
+	fIgnoreMissing := cmdCheck.Flags().Bool("ignore-missing", false, "do not report missing and extra entries")
+	cmdCheck.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("expected two arguments")
+		}
+		cmd.SilenceUsage = true
+		ref, err := readStrings(args[0])
+		if err != nil {
+			return err
+		}
+		tr, err := readStrings(args[1])
+		if err != nil {
+			return err
+		}
+		issues := strman.Check(ref, tr, &strman.CheckOptions{
+			MaxLineLen:    *fMaxLine,
+			MaxLineRatio:  *fLineRatio,
+			IgnoreMissing: *fIgnoreMissing,
+		})
+		w := cmd.OutOrStdout()
+		if *fCheckJSON {
+			if issues == nil {
+				issues = []strman.Issue{}
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "\t")
+			if err = enc.Encode(issues); err != nil {
+				return err
+			}
+		} else {
+			for _, e := range issues {
+				fmt.Fprintln(w, e)
+			}
+		}
+		if len(issues) != 0 {
+			return fmt.Errorf("found %d issues", len(issues))
+		}
+		return nil
+	}
+}
+
+// readStrings reads a string file, selecting the format by the extension.
+func readStrings(path string) (*strman.StringManager, error) {
+	sm := strman.New()
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = sm.ReadJSON(path)
+	case ".str":
+		err = sm.ReadSTR(path)
+	case ".po":
+		err = sm.ReadPO(path, nil)
+	default:
+		err = sm.ReadCSF(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
+
+// writeStrings writes a string file, selecting the format by the extension.
+func writeStrings(sm *strman.StringManager, path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return sm.WriteJSON(path, true)
+	case ".str":
+		return sm.WriteSTR(path)
+	default:
+		return sm.WriteCSF(path)
+	}
 }
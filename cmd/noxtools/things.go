@@ -1,12 +1,18 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/noxworld-dev/opennox-lib/bag"
+	"github.com/noxworld-dev/opennox-lib/bag/anim"
 	"github.com/noxworld-dev/opennox-lib/things"
 )
 
@@ -52,4 +58,204 @@ func init() {
 		}
 		return writeJSON(out, data, *fPretty)
 	}
+
+	cmdThing := &cobra.Command{
+		Use:     "json2thing input output",
+		Short:   "Compiles JSON file back to Nox thing.bin file",
+		Aliases: []string{"j2t"},
+	}
+	cmd.AddCommand(cmdThing)
+	cmdThing.RunE = func(cmd *cobra.Command, args []string) error {
+		var (
+			in  string
+			out string
+		)
+		if len(args) == 1 {
+			in = args[0]
+			out = filepath.Join(filepath.Dir(in), "thing.bin")
+		} else if len(args) == 2 {
+			in = args[0]
+			out = args[1]
+		} else {
+			return errors.New("expected one or two arguments")
+		}
+		data, err := os.ReadFile(in)
+		if err != nil {
+			return err
+		}
+		var d things.Data
+		if err = json.Unmarshal(data, &d); err != nil {
+			return err
+		}
+		return writeThingsData(out, &d)
+	}
+
+	cmdPatch := &cobra.Command{
+		Use:   "patch input patch.yml [patch2.yml ...]",
+		Short: "Applies YAML patches to Nox thing.bin file",
+		Long: `Applies YAML patches to Nox thing.bin file in order and writes the result.
+Input can be either thing.bin or JSON file produced by thing2json.
+Output is written as JSON if the file has .json extension, or as thing.bin otherwise.`,
+	}
+	cmd.AddCommand(cmdPatch)
+	fPatchOut := cmdPatch.Flags().StringP("out", "o", "thing.bin", "output file")
+	fPatchStrict := cmdPatch.Flags().Bool("strict", false, "fail if patches conflict with each other")
+	cmdPatch.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected input file and at least one patch")
+		}
+		d, err := readThingsData(args[0])
+		if err != nil {
+			return err
+		}
+		var patches []*things.Patch
+		for _, path := range args[1:] {
+			p, err := things.ReadPatchYAML(path)
+			if err != nil {
+				return err
+			}
+			patches = append(patches, p)
+		}
+		conflicts, err := d.ApplyPatches(patches...)
+		for _, c := range conflicts {
+			log.Println("conflict:", c)
+		}
+		if err != nil {
+			return err
+		}
+		if *fPatchStrict && len(conflicts) != 0 {
+			return errors.New("patches conflict with each other")
+		}
+		return writeThingsData(*fPatchOut, d)
+	}
+
+	cmdAnim := &cobra.Command{
+		Use:   "anim [--bag video.bag] [--format gif] [--out ./out] thing.bin name [name2 ...]",
+		Short: "Exports thing animations as animated GIF or APNG files",
+		Long: `Exports animations of the given things as animated GIF or APNG files.
+Input can be either thing.bin or JSON file produced by thing2json.
+Monster and player animations are exported as separate files for each animation and direction.`,
+	}
+	cmd.AddCommand(cmdAnim)
+	fAnimBag := cmdAnim.Flags().String("bag", "", "path to video.bag file (default is the one next to thing.bin)")
+	fAnimFormat := cmdAnim.Flags().StringP("format", "f", "gif", "output format: gif or apng")
+	fAnimOut := cmdAnim.Flags().StringP("out", "o", ".", "output directory")
+	fAnimDelay := cmdAnim.Flags().Duration("delay", 0, "override frame delay")
+	cmdAnim.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected input file and at least one thing name")
+		}
+		var ext string
+		switch *fAnimFormat {
+		case "gif":
+			ext = ".gif"
+		case "apng", "png":
+			ext = ".png"
+		default:
+			return fmt.Errorf("unsupported format: %q", *fAnimFormat)
+		}
+		cmd.SilenceUsage = true
+		d, err := readThingsData(args[0])
+		if err != nil {
+			return err
+		}
+		bpath := *fAnimBag
+		if bpath == "" {
+			bpath = filepath.Join(filepath.Dir(args[0]), "video.bag")
+		}
+		f, err := bag.Open(bpath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err = os.MkdirAll(*fAnimOut, 0755); err != nil {
+			return err
+		}
+		for _, name := range args[1:] {
+			th := d.ThingByName(name)
+			if th == nil {
+				return fmt.Errorf("cannot find thing: %q", name)
+			}
+			seqs, err := anim.Sequences(f, d, th, &anim.Options{Delay: *fAnimDelay})
+			if err != nil {
+				return err
+			}
+			if len(seqs) == 0 {
+				log.Printf("thing %q has no animations", name)
+				continue
+			}
+			for _, s := range seqs {
+				fname := th.Name
+				if s.Name != "" {
+					fname += "_" + s.Name
+				}
+				if err = writeAnimation(filepath.Join(*fAnimOut, fname+ext), s); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// writeAnimation writes animation sequence as GIF or APNG, depending on the file extension.
+func writeAnimation(path string, s *anim.Sequence) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if filepath.Ext(path) == ".gif" {
+		err = s.WriteGIF(f)
+	} else {
+		err = s.WriteAPNG(f)
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// readThingsData reads thing.bin data either from the binary file or from JSON.
+func readThingsData(path string) (*things.Data, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var d things.Data
+		if err = json.Unmarshal(data, &d); err != nil {
+			return nil, err
+		}
+		return &d, nil
+	}
+	f, err := things.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.ReadAll()
+}
+
+// writeThingsData writes thing.bin data either as JSON or as encrypted binary file, depending on file extension.
+func writeThingsData(path string, d *things.Data) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return writeJSON(path, d, true)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := things.CreateWriter(f, 0)
+	if err != nil {
+		return err
+	}
+	if err = w.WriteAll(d); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return f.Close()
 }
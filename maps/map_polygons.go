@@ -59,7 +59,14 @@ type Polygon struct {
 }
 
 func (w *Polygon) EncodingSize() int {
-	return 1 + len(w.Name) + 3 + 1 + 2 + 4*len(w.Points) + w.PlayerEnter.EncodingSize() + w.MonsterEnter.EncodingSize() + 4
+	sz := 1 + len(w.Name) + 3 + 1 + 2 + 4*len(w.Points) + 4
+	if w.PlayerEnter != nil {
+		sz += w.PlayerEnter.EncodingSize()
+	}
+	if w.MonsterEnter != nil {
+		sz += w.MonsterEnter.EncodingSize()
+	}
+	return sz
 }
 
 func (w *Polygon) MarshalBinary(vers uint16) ([]byte, error) {
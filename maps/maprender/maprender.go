@@ -10,7 +10,6 @@ import (
 	"github.com/noxworld-dev/opennox-lib/bag"
 	"github.com/noxworld-dev/opennox-lib/common"
 	"github.com/noxworld-dev/opennox-lib/maps"
-	"github.com/noxworld-dev/opennox-lib/noximage/pcx"
 	"github.com/noxworld-dev/opennox-lib/things"
 )
 
@@ -50,11 +49,10 @@ func NewRenderer(datadir string) (*Renderer, error) {
 
 // Renderer is a Nox map renderer.
 type Renderer struct {
-	tng        *things.Reader
-	bag        *bag.File
-	wallByMat  map[int]*things.Wall
-	images     []*bag.ImageRec
-	imageByInd map[int]*pcx.Image
+	tng       *things.Reader
+	bag       *bag.File
+	wallByMat map[int]*things.Wall
+	images    *bag.Cache
 }
 
 // Close the renderer,
@@ -77,24 +75,22 @@ func (r *Renderer) indexThingWalls() error {
 }
 
 func (r *Renderer) indexBagImages() error {
-	imgs, err := r.bag.Images()
+	c, err := bag.NewCache(r.bag, nil)
 	if err != nil {
 		return err
 	}
-	r.images = imgs
-	r.imageByInd = make(map[int]*pcx.Image)
+	r.images = c
 	return nil
 }
 
 func (r *Renderer) getImage(ind int) (image.Image, image.Point, error) {
-	img, ok := r.imageByInd[ind]
-	if !ok {
-		var err error
-		img, err = r.images[ind].Decode()
-		if err != nil {
-			return nil, image.Point{}, err
-		}
-		r.imageByInd[ind] = img
+	rec := r.images.Image(ind)
+	if rec == nil {
+		return nil, image.Point{}, fmt.Errorf("image index out of bounds: %d", ind)
+	}
+	img, err := r.images.Decode(rec)
+	if err != nil {
+		return nil, image.Point{}, err
 	}
 	return img.Image, img.Point, nil
 }
@@ -0,0 +1,91 @@
+package maps
+
+import (
+	"fmt"
+	"io"
+)
+
+// UpgradeSection converts a known map section to the latest version supported by MarshalBinary.
+// It returns false if the section was already at the latest version.
+//
+// Objects section is never upgraded, since its version applies to the object data, which is not decoded here.
+func UpgradeSection(s Section) bool {
+	switch s := s.(type) {
+	case *MapInfo:
+		// format 3 implies fixed player limits, so format 2 is the latest one that preserves all data
+		if s.Format < 2 {
+			s.Format = 2
+			if s.MinPlayers == 0 && s.MaxPlayers == 0 {
+				s.MinPlayers, s.MaxPlayers = 2, 16
+			}
+			return true
+		}
+	case *Polygons:
+		if s.Vers < 4 {
+			for i := range s.Polygons {
+				p := &s.Polygons[i]
+				if p.PlayerEnter == nil {
+					p.PlayerEnter = new(ScriptHandler)
+				}
+				if p.MonsterEnter == nil {
+					p.MonsterEnter = new(ScriptHandler)
+				}
+			}
+			s.Vers = 4
+			return true
+		}
+	case *ObjectsTOC:
+		if s.Vers < 1 {
+			s.Vers = 1
+			return true
+		}
+	}
+	return false
+}
+
+// UpgradeRawSection decodes a raw map section, upgrades it with UpgradeSection and encodes it back.
+// Unsupported sections are returned unchanged.
+func UpgradeRawSection(s RawSection) (RawSection, error) {
+	if !s.Supported() {
+		return s, nil
+	}
+	d, err := s.Decode()
+	if err != nil {
+		return s, fmt.Errorf("cannot decode %s: %w", s.Name, err)
+	}
+	UpgradeSection(d)
+	data, err := d.MarshalBinary()
+	if err != nil {
+		return s, fmt.Errorf("cannot encode %s: %w", s.Name, err)
+	}
+	return RawSection{Name: s.Name, Data: data}, nil
+}
+
+// Upgrade reads a map file with either Magic or MagicOld header from r and writes it to w with the Magic header.
+// All supported sections are normalised to the latest version, see UpgradeSection.
+func Upgrade(w WriterAt, r io.Reader) error {
+	rd, err := NewReader(r)
+	if err != nil {
+		return err
+	}
+	raw, err := rd.ReadSectionsRaw()
+	if err != nil {
+		return err
+	}
+	for i, s := range raw {
+		raw[i], err = UpgradeRawSection(s)
+		if err != nil {
+			return err
+		}
+	}
+	hdr := rd.Header()
+	hdr.Magic = Magic
+	wr, err := NewWriter(w, hdr)
+	if err != nil {
+		return err
+	}
+	if err = wr.WriteRawSections(raw); err != nil {
+		return err
+	}
+	return wr.Close()
+}
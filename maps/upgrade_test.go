@@ -0,0 +1,72 @@
+package maps_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/maps"
+	"github.com/noxworld-dev/opennox-lib/types"
+)
+
+func TestUpgrade(t *testing.T) {
+	var old buffer
+	wr, err := maps.NewWriter(&old, maps.Header{Magic: maps.MagicOld})
+	must.NoError(t, err)
+	err = wr.WriteSections([]maps.Section{
+		&maps.MapInfo{Format: 1, Summary: "Old map", Flags: 0x1},
+		&maps.Polygons{
+			Vers:   1,
+			Points: []maps.PolygonPoint{{ID: 1, Pos: types.Pointf{X: 10, Y: 20}}},
+			Polygons: []maps.Polygon{
+				{Name: "room", AmbientLight: types.RGB{R: 1, G: 2, B: 3}, Points: []uint32{1}},
+			},
+		},
+		&maps.ObjectsTOC{TOC: []maps.ObjectTOC{{Ind: 1, Type: "Gold"}}},
+		&maps.Objects{Data: []byte{4, 5, 6}},
+	})
+	must.NoError(t, err)
+	err = wr.WriteRawSection(maps.RawSection{Name: "DebugData", Data: []byte{1, 2, 3}})
+	must.NoError(t, err)
+	err = wr.Close()
+	must.NoError(t, err)
+
+	var got buffer
+	err = maps.Upgrade(&got, bytes.NewReader(old.Bytes()))
+	must.NoError(t, err)
+
+	rd, err := maps.NewReader(bytes.NewReader(got.Bytes()))
+	must.NoError(t, err)
+	must.EqOp(t, uint32(maps.Magic), rd.Header().Magic)
+	raw, err := rd.ReadSectionsRaw()
+	must.NoError(t, err)
+	must.SliceLen(t, 5, raw)
+
+	s, err := raw[0].Decode()
+	must.NoError(t, err)
+	info := s.(*maps.MapInfo)
+	must.EqOp(t, 2, info.Format)
+	must.EqOp(t, "Old map", info.Summary)
+	must.EqOp(t, 2, info.MinPlayers)
+	must.EqOp(t, 16, info.MaxPlayers)
+
+	s, err = raw[1].Decode()
+	must.NoError(t, err)
+	poly := s.(*maps.Polygons)
+	must.EqOp(t, 4, poly.Vers)
+	must.SliceLen(t, 1, poly.Polygons)
+	must.EqOp(t, "room", poly.Polygons[0].Name)
+	must.NotNil(t, poly.Polygons[0].PlayerEnter)
+
+	s, err = raw[2].Decode()
+	must.NoError(t, err)
+	must.EqOp(t, 1, s.(*maps.ObjectsTOC).Vers)
+
+	// object data is not decoded, so its version must be preserved
+	s, err = raw[3].Decode()
+	must.NoError(t, err)
+	must.Eq(t, &maps.Objects{Data: []byte{4, 5, 6}}, s.(*maps.Objects))
+
+	must.Eq(t, maps.RawSection{Name: "DebugData", Data: []byte{1, 2, 3}}, raw[4])
+}
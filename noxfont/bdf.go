@@ -0,0 +1,360 @@
+package noxfont
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BDF properties that store Nox-specific font layout.
+const (
+	bdfPropInline = "NOX_INLINE"
+	bdfPropField0 = "NOX_FIELD0"
+	bdfPropField1 = "NOX_FIELD1"
+	bdfPropStride = "NOX_STRIDE"
+	bdfPropRanges = "NOX_RANGES"
+	bdfPropCP1251 = "NOX_CP1251"
+)
+
+// EncodeBDF writes the font in BDF text format.
+//
+// Glyph cells are written as-is, with the baseline at the bottom of the cell.
+// Range layout, font format variant and unknown header fields are stored as custom properties,
+// so that DecodeBDF can restore them.
+func (f *Font) EncodeBDF(w io.Writer, name string) error {
+	var (
+		height int
+		stride int
+		maxW   int
+		chars  int
+		ranges []string
+	)
+	for _, r := range f.Ranges {
+		ranges = append(ranges, fmt.Sprintf("%X-%X", r.StartChar, r.EndChar))
+		for _, g := range r.Glyphs {
+			if stride == 0 {
+				stride, height = g.Stride, g.Rect.Dy()
+			}
+			maxW = max(maxW, g.Rect.Dx())
+			chars++
+		}
+	}
+	if name == "" {
+		name = "nox"
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "STARTFONT 2.1\n")
+	fmt.Fprintf(bw, "FONT %s\n", name)
+	fmt.Fprintf(bw, "SIZE %d 75 75\n", height)
+	fmt.Fprintf(bw, "FONTBOUNDINGBOX %d %d 0 0\n", maxW, height)
+	props := []string{
+		fmt.Sprintf("FONT_ASCENT %d", height),
+		"FONT_DESCENT 0",
+		fmt.Sprintf("%s %d", bdfPropInline, boolInt(f.Inline)),
+		fmt.Sprintf("%s %d", bdfPropField0, f.Field0),
+		fmt.Sprintf("%s %d", bdfPropField1, f.Field1),
+		fmt.Sprintf("%s %d", bdfPropStride, stride),
+		fmt.Sprintf("%s %q", bdfPropRanges, strings.Join(ranges, " ")),
+	}
+	if f.CP1251 {
+		props = append(props, bdfPropCP1251+" 1")
+	} else {
+		props = append(props, `CHARSET_REGISTRY "ISO10646"`, `CHARSET_ENCODING "1"`)
+	}
+	fmt.Fprintf(bw, "STARTPROPERTIES %d\n", len(props))
+	for _, p := range props {
+		fmt.Fprintln(bw, p)
+	}
+	fmt.Fprintf(bw, "ENDPROPERTIES\n")
+	fmt.Fprintf(bw, "CHARS %d\n", chars)
+	for _, r := range f.Ranges {
+		for i, g := range r.Glyphs {
+			c := int(r.StartChar) + i
+			gw, gh := g.Rect.Dx(), g.Rect.Dy()
+			fmt.Fprintf(bw, "STARTCHAR U+%04X\n", c)
+			fmt.Fprintf(bw, "ENCODING %d\n", c)
+			fmt.Fprintf(bw, "SWIDTH %d 0\n", (gw+1)*1000/max(height, 1))
+			fmt.Fprintf(bw, "DWIDTH %d 0\n", gw+1)
+			if gw == 0 {
+				fmt.Fprintf(bw, "BBX 0 0 0 0\nBITMAP\n")
+			} else {
+				fmt.Fprintf(bw, "BBX %d %d 0 0\nBITMAP\n", gw, gh)
+				n := (gw + 7) / 8
+				for y := 0; y < gh; y++ {
+					fmt.Fprintf(bw, "%X\n", g.Pix[y*g.Stride:y*g.Stride+n])
+				}
+			}
+			fmt.Fprintf(bw, "ENDCHAR\n")
+		}
+	}
+	fmt.Fprintf(bw, "ENDFONT\n")
+	return bw.Flush()
+}
+
+func boolInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+type bdfGlyph struct {
+	enc    int
+	dwidth int // -1 if not set
+	bbx    image.Rectangle
+	xoff   int
+	yoff   int
+	rows   [][]byte
+}
+
+// DecodeBDF reads a font in BDF text format.
+//
+// Fonts written by EncodeBDF are restored with the same range layout and format variant.
+// For other fonts, glyph cell height is the sum of the font ascent and descent,
+// and ranges are built from consecutive character codes.
+func DecodeBDF(r io.Reader) (*Font, error) {
+	var (
+		f       Font
+		props   = make(map[string]string)
+		glyphs  []*bdfGlyph
+		cur     *bdfGlyph
+		inBits  bool
+		inProps bool
+		bbox    image.Rectangle
+		bboxY   int
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if s == "" {
+			continue
+		}
+		key, val, _ := strings.Cut(s, " ")
+		val = strings.TrimSpace(val)
+		if inBits {
+			if key == "ENDCHAR" {
+				inBits, cur = false, nil
+				continue
+			}
+			row, err := hex.DecodeString(s)
+			if err != nil {
+				return nil, fmt.Errorf("bdf: line %d: invalid bitmap: %w", line, err)
+			}
+			cur.rows = append(cur.rows, row)
+			continue
+		}
+		if inProps {
+			if key == "ENDPROPERTIES" {
+				inProps = false
+			} else {
+				props[key] = strings.Trim(val, `"`)
+			}
+			continue
+		}
+		ints := func(n int) ([]int, error) {
+			fields := strings.Fields(val)
+			if len(fields) < n {
+				return nil, fmt.Errorf("bdf: line %d: expected %d values for %s", line, n, key)
+			}
+			out := make([]int, n)
+			for i := range out {
+				v, err := strconv.Atoi(fields[i])
+				if err != nil {
+					return nil, fmt.Errorf("bdf: line %d: %w", line, err)
+				}
+				out[i] = v
+			}
+			return out, nil
+		}
+		switch key {
+		case "STARTPROPERTIES":
+			inProps = true
+		case "FONTBOUNDINGBOX":
+			v, err := ints(4)
+			if err != nil {
+				return nil, err
+			}
+			bbox = image.Rect(0, 0, v[0], v[1])
+			bboxY = v[3]
+		case "STARTCHAR":
+			cur = &bdfGlyph{enc: -1, dwidth: -1}
+		case "ENCODING", "DWIDTH":
+			if cur == nil {
+				return nil, fmt.Errorf("bdf: line %d: %s outside of a glyph", line, key)
+			}
+			v, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			if key == "ENCODING" {
+				cur.enc = v[0]
+			} else {
+				cur.dwidth = v[0]
+			}
+		case "BBX":
+			if cur == nil {
+				return nil, fmt.Errorf("bdf: line %d: %s outside of a glyph", line, key)
+			}
+			v, err := ints(4)
+			if err != nil {
+				return nil, err
+			}
+			cur.bbx = image.Rect(0, 0, v[0], v[1])
+			cur.xoff, cur.yoff = v[2], v[3]
+		case "BITMAP":
+			if cur == nil {
+				return nil, fmt.Errorf("bdf: line %d: bitmap outside of a glyph", line)
+			}
+			inBits = true
+			glyphs = append(glyphs, cur)
+		case "ENDCHAR":
+			cur = nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	propInt := func(name string) (int, bool, error) {
+		s, ok := props[name]
+		if !ok {
+			return 0, false, nil
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, false, fmt.Errorf("bdf: invalid %s property: %w", name, err)
+		}
+		return v, true, nil
+	}
+
+	ascent, ok, err := propInt("FONT_ASCENT")
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		ascent = bbox.Dy() + bboxY
+	}
+	descent, ok, err := propInt("FONT_DESCENT")
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		descent = -bboxY
+	}
+	height := ascent + descent
+	if height <= 0 {
+		return nil, errors.New("bdf: invalid font height")
+	}
+	var inline, cp1251, field0, field1 int
+	for _, p := range []struct {
+		name string
+		ptr  *int
+	}{
+		{bdfPropInline, &inline},
+		{bdfPropCP1251, &cp1251},
+		{bdfPropField0, &field0},
+		{bdfPropField1, &field1},
+	} {
+		if *p.ptr, _, err = propInt(p.name); err != nil {
+			return nil, err
+		}
+	}
+	f.Inline = inline != 0
+	f.CP1251 = cp1251 != 0
+	f.Field0 = uint32(field0)
+	f.Field1 = uint32(field1)
+	stride, _, err := propInt(bdfPropStride)
+	if err != nil {
+		return nil, err
+	}
+
+	// render glyphs into cells
+	byChar := make(map[uint16]*image.Alpha)
+	maxW := 0
+	for _, g := range glyphs {
+		if g.enc < 0 {
+			continue // unencoded glyph
+		} else if g.enc > 0xffff {
+			return nil, fmt.Errorf("bdf: char %U is not supported", g.enc)
+		}
+		w := max(g.dwidth-1, g.xoff+g.bbx.Dx(), 0)
+		if g.dwidth < 0 {
+			w = max(g.xoff+g.bbx.Dx(), 0)
+		}
+		if w > 0xff {
+			return nil, fmt.Errorf("bdf: char %U: glyph is too large", g.enc)
+		}
+		img := image.NewAlpha(image.Rect(0, 0, w, height))
+		top := ascent - (g.yoff + g.bbx.Dy())
+		for y, row := range g.rows {
+			for x := 0; x < g.bbx.Dx() && x/8 < len(row); x++ {
+				if row[x/8]&(0x80>>(x%8)) != 0 {
+					img.SetAlpha(g.xoff+x, top+y, color.Alpha{A: 0xff})
+				}
+			}
+		}
+		byChar[uint16(g.enc)] = img
+		maxW = max(maxW, w)
+	}
+	if len(byChar) == 0 {
+		return nil, errors.New("bdf: font has no glyphs")
+	}
+	stride = max(stride, (maxW+7)/8, 1)
+
+	// restore the original layout, if possible
+	var layout []Range
+	if s := props[bdfPropRanges]; s != "" {
+		for _, part := range strings.Fields(s) {
+			cr, err := ParseCharRanges(part)
+			if err != nil || len(cr) != 1 {
+				return nil, fmt.Errorf("bdf: invalid %s property: %q", bdfPropRanges, part)
+			}
+			layout = append(layout, Range{StartChar: uint16(cr[0].First), EndChar: uint16(cr[0].Last)})
+		}
+	}
+	covered := func(c uint16) bool {
+		for _, r := range layout {
+			if c >= r.StartChar && c <= r.EndChar {
+				return true
+			}
+		}
+		return false
+	}
+	// glyphs outside the layout are added as new ranges
+	var extra []uint16
+	for c := range byChar {
+		if !covered(c) {
+			extra = append(extra, c)
+		}
+	}
+	slices.Sort(extra)
+	for _, c := range extra {
+		if n := len(layout); n != 0 && layout[n-1].EndChar+1 == c {
+			layout[n-1].EndChar = c
+			continue
+		}
+		layout = append(layout, Range{StartChar: c, EndChar: c})
+	}
+	empty := image.NewAlpha(image.Rect(0, 0, 0, height))
+	for _, r := range layout {
+		for c := int(r.StartChar); c <= int(r.EndChar); c++ {
+			img := byChar[uint16(c)]
+			if img == nil {
+				img = empty
+			}
+			r.Glyphs = append(r.Glyphs, toBitmap(img, stride, 128))
+		}
+		f.Ranges = append(f.Ranges, r)
+	}
+	if f.Inline && len(f.Ranges) > 8 {
+		return nil, fmt.Errorf("bdf: too many ranges for inline format: %d", len(f.Ranges))
+	}
+	return &f, nil
+}
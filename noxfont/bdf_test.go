@@ -0,0 +1,127 @@
+package noxfont
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestBDFRoundTrip(t *testing.T) {
+	f, err := opentype.Parse(goregular.TTF)
+	must.NoError(t, err)
+	for _, inline := range []bool{false, true} {
+		fnt, err := Rasterize(f, &RasterOptions{
+			Size:   14,
+			Chars:  []CharRange{{First: 0x20, Last: 0x7e}, {First: 0x400, Last: 0x44f}},
+			Inline: inline,
+		})
+		must.NoError(t, err)
+		fnt.Field0, fnt.Field1 = 3, 7
+		// keep an empty glyph in the middle of a range
+		rng := &fnt.Ranges[0]
+		g := rng.Glyphs[1]
+		rng.Glyphs[1] = &Bitmap{Pix: make([]byte, len(g.Pix)), Stride: g.Stride, Rect: g.Rect}
+		rng.Glyphs[1].Rect.Max.X = 0
+
+		var buf bytes.Buffer
+		err = fnt.EncodeBDF(&buf, "test")
+		must.NoError(t, err)
+		must.StrContains(t, buf.String(), "STARTCHAR U+0041\n")
+
+		fnt2, err := DecodeBDF(&buf)
+		must.NoError(t, err)
+		must.Eq(t, fnt, fnt2)
+
+		exp, err := fnt.Encode()
+		must.NoError(t, err)
+		got, err := fnt2.Encode()
+		must.NoError(t, err)
+		must.Eq(t, exp, got)
+	}
+}
+
+const testBDF = `STARTFONT 2.1
+FONT -misc-test-medium-r-normal--8-80-75-75-c-60-iso10646-1
+SIZE 8 75 75
+FONTBOUNDINGBOX 5 8 0 -2
+STARTPROPERTIES 2
+FONT_ASCENT 6
+FONT_DESCENT 2
+ENDPROPERTIES
+CHARS 4
+STARTCHAR A
+ENCODING 65
+SWIDTH 750 0
+DWIDTH 6 0
+BBX 5 6 0 0
+BITMAP
+20
+50
+88
+F8
+88
+88
+ENDCHAR
+STARTCHAR B
+ENCODING 66
+DWIDTH 6 0
+BBX 4 6 0 0
+BITMAP
+F0
+88
+F0
+88
+88
+F0
+ENDCHAR
+STARTCHAR g
+ENCODING 103
+DWIDTH 5 0
+BBX 4 5 0 -2
+BITMAP
+70
+90
+70
+10
+E0
+ENDCHAR
+STARTCHAR unencoded
+ENCODING -1
+DWIDTH 5 0
+BBX 1 1 0 0
+BITMAP
+80
+ENDCHAR
+ENDFONT
+`
+
+func TestDecodeBDF(t *testing.T) {
+	fnt, err := DecodeBDF(strings.NewReader(testBDF))
+	must.NoError(t, err)
+	must.False(t, fnt.Inline)
+	must.Len(t, 2, fnt.Ranges)
+	must.EqOp(t, 'A', fnt.Ranges[0].StartChar)
+	must.EqOp(t, 'B', fnt.Ranges[0].EndChar)
+	must.EqOp(t, 'g', fnt.Ranges[1].StartChar)
+
+	a := fnt.Rune('A')
+	must.EqOp(t, 5, a.Rect.Dx())
+	must.EqOp(t, 8, a.Rect.Dy())
+	must.EqOp(t, 1, a.Stride)
+	must.True(t, a.at(2, 0))
+	must.True(t, a.at(4, 5))
+	must.False(t, a.at(0, 6))
+
+	g := fnt.Rune('g')
+	must.EqOp(t, 4, g.Rect.Dx())
+	must.True(t, g.at(1, 3)) // top of the glyph is below the ascent
+	must.False(t, g.at(1, 2))
+	must.True(t, g.at(0, 7)) // descent
+
+	_, err = fnt.Encode()
+	must.NoError(t, err)
+}
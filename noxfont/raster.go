@@ -0,0 +1,195 @@
+package noxfont
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// CharRange is an inclusive range of Unicode characters.
+type CharRange struct {
+	First rune
+	Last  rune
+}
+
+// DefaultCharRanges is the set of characters used when no ranges are specified: printable ASCII and Latin-1.
+var DefaultCharRanges = []CharRange{
+	{First: 0x20, Last: 0x7e},
+	{First: 0xa0, Last: 0xff},
+}
+
+// ParseCharRanges parses a comma-separated list of hex character ranges, for example: "20-7E,U+0400-U+04FF,A9".
+func ParseCharRanges(s string) ([]CharRange, error) {
+	var out []CharRange
+	parse := func(s string) (rune, error) {
+		s = strings.TrimSpace(s)
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "U+"), "u+")
+		v, err := strconv.ParseUint(s, 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid character code: %q", s)
+		}
+		return rune(v), nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		first, last, ok := strings.Cut(part, "-")
+		var (
+			r   CharRange
+			err error
+		)
+		if r.First, err = parse(first); err != nil {
+			return nil, err
+		}
+		r.Last = r.First
+		if ok {
+			if r.Last, err = parse(last); err != nil {
+				return nil, err
+			}
+		}
+		if r.Last < r.First {
+			return nil, fmt.Errorf("invalid character range: %q", part)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// RasterOptions controls font rasterization.
+type RasterOptions struct {
+	// Size of the font in pixels.
+	Size float64
+	// Chars is a set of characters to include. If not set, DefaultCharRanges is used.
+	// Only characters from the Basic Multilingual Plane are supported.
+	Chars []CharRange
+	// Threshold is the minimal coverage of a pixel, for it to be set in the bitmap. Default is 128.
+	Threshold uint8
+	// Inline selects a legacy font format. It supports at most 8 ranges.
+	Inline bool
+}
+
+// Rasterize converts a TrueType or OpenType font to a Nox bitmap font.
+//
+// All glyphs have the same height, equal to the font line height with the baseline at the font ascent.
+// Glyph width is derived from the glyph advance, since the engine adds one pixel between glyphs.
+// Characters missing from the font are not included, so ranges may be split.
+func Rasterize(f *opentype.Font, opts *RasterOptions) (*Font, error) {
+	if opts == nil || opts.Size <= 0 {
+		return nil, errors.New("font size must be set")
+	}
+	chars := opts.Chars
+	if len(chars) == 0 {
+		chars = DefaultCharRanges
+	}
+	thresh := opts.Threshold
+	if thresh == 0 {
+		thresh = 128
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    opts.Size,
+		DPI:     72, // size is in pixels
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+	m := face.Metrics()
+	ascent := m.Ascent.Ceil()
+	height := ascent + m.Descent.Ceil()
+	if height <= 0 {
+		return nil, errors.New("invalid font metrics")
+	}
+
+	runes, err := sortedRunes(chars)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		buf    sfnt.Buffer
+		out    = &Font{Inline: opts.Inline}
+		glyphs [][]*image.Alpha
+		maxW   int
+	)
+	for _, r := range runes {
+		if ind, err := f.GlyphIndex(&buf, r); err != nil {
+			return nil, fmt.Errorf("char %U: %w", r, err)
+		} else if ind == 0 {
+			continue // missing
+		}
+		dr, mask, maskp, adv, ok := face.Glyph(fixed.P(0, ascent), r)
+		if !ok {
+			continue
+		}
+		w := max(adv.Round()-1, dr.Max.X, 0)
+		if w > 0xff {
+			return nil, fmt.Errorf("char %U: glyph is too large", r)
+		}
+		img := image.NewAlpha(image.Rect(0, 0, w, height))
+		if mask != nil {
+			draw.DrawMask(img, dr, image.Opaque, image.Point{}, mask, maskp, draw.Over)
+		}
+		maxW = max(maxW, w)
+		c := uint16(r)
+		if n := len(out.Ranges); n != 0 && out.Ranges[n-1].EndChar+1 == c {
+			out.Ranges[n-1].EndChar = c
+			glyphs[n-1] = append(glyphs[n-1], img)
+		} else {
+			out.Ranges = append(out.Ranges, Range{StartChar: c, EndChar: c})
+			glyphs = append(glyphs, []*image.Alpha{img})
+		}
+	}
+	if len(out.Ranges) == 0 {
+		return nil, errors.New("font has no glyphs for selected characters")
+	}
+	if out.Inline && len(out.Ranges) > 8 {
+		return nil, fmt.Errorf("too many ranges for inline format: %d", len(out.Ranges))
+	}
+	stride := max((maxW+7)/8, 1)
+	for i := range out.Ranges {
+		rng := &out.Ranges[i]
+		for _, img := range glyphs[i] {
+			rng.Glyphs = append(rng.Glyphs, toBitmap(img, stride, thresh))
+		}
+	}
+	return out, nil
+}
+
+// sortedRunes returns a sorted list of unique characters in ranges.
+func sortedRunes(chars []CharRange) ([]rune, error) {
+	var out []rune
+	for _, r := range chars {
+		if r.Last > 0xffff {
+			return nil, fmt.Errorf("char %U is not supported", r.Last)
+		}
+		for c := r.First; c <= r.Last; c++ {
+			out = append(out, c)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+func toBitmap(img *image.Alpha, stride int, thresh uint8) *Bitmap {
+	sz := img.Rect.Size()
+	b := &Bitmap{Pix: make([]byte, stride*sz.Y), Stride: stride, Rect: img.Rect}
+	for y := 0; y < sz.Y; y++ {
+		for x := 0; x < sz.X; x++ {
+			if img.AlphaAt(x, y).A >= thresh {
+				i, j := b.BitOffsets(x, y)
+				b.Pix[i] |= 1 << j
+			}
+		}
+	}
+	return b
+}
@@ -0,0 +1,77 @@
+package noxfont
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shoenig/test/must"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestParseCharRanges(t *testing.T) {
+	got, err := ParseCharRanges("20-7E, U+0400-U+04FF,a9")
+	must.NoError(t, err)
+	must.Eq(t, []CharRange{
+		{First: 0x20, Last: 0x7e},
+		{First: 0x400, Last: 0x4ff},
+		{First: 0xa9, Last: 0xa9},
+	}, got)
+
+	got, err = ParseCharRanges("")
+	must.NoError(t, err)
+	must.Len(t, 0, got)
+
+	_, err = ParseCharRanges("7E-20")
+	must.Error(t, err)
+	_, err = ParseCharRanges("xyz")
+	must.Error(t, err)
+}
+
+func TestRasterize(t *testing.T) {
+	f, err := opentype.Parse(goregular.TTF)
+	must.NoError(t, err)
+	fnt, err := Rasterize(f, &RasterOptions{
+		Size: 12,
+		// Go font has no glyphs for 0x80-0x9f, so the range must be split
+		Chars: []CharRange{{First: 'A', Last: 'Z'}, {First: 0x7e, Last: 0xa0}, {First: 0x20, Last: 0x20}},
+	})
+	must.NoError(t, err)
+	must.Len(t, 4, fnt.Ranges)
+	must.EqOp(t, ' ', fnt.Ranges[0].StartChar)
+	must.EqOp(t, 'A', fnt.Ranges[1].StartChar)
+	must.EqOp(t, 'Z', fnt.Ranges[1].EndChar)
+	must.EqOp(t, 0x7e, fnt.Ranges[2].StartChar)
+	must.EqOp(t, 0x7e, fnt.Ranges[2].EndChar)
+	must.EqOp(t, 0xa0, fnt.Ranges[3].StartChar)
+	must.Nil(t, fnt.Rune(0x90))
+
+	space, m := fnt.Rune(' '), fnt.Rune('M')
+	must.NotNil(t, m)
+	must.EqOp(t, space.Rect.Dy(), m.Rect.Dy())
+	must.EqOp(t, space.Stride, m.Stride)
+	must.Greater(t, space.Rect.Dx(), m.Rect.Dx())
+	set := 0
+	for y := 0; y < m.Rect.Dy(); y++ {
+		for x := 0; x < m.Rect.Dx(); x++ {
+			if m.at(x, y) {
+				set++
+			}
+		}
+	}
+	must.Positive(t, set)
+	for _, b := range space.Pix {
+		must.EqOp(t, 0, b)
+	}
+
+	data, err := fnt.Encode()
+	must.NoError(t, err)
+	fnt2, err := Decode(bytes.NewReader(data))
+	must.NoError(t, err)
+	must.Eq(t, fnt.Ranges, fnt2.Ranges)
+
+	_, err = Rasterize(f, &RasterOptions{Size: 12, Chars: []CharRange{{First: 0x10000, Last: 0x10000}}})
+	must.Error(t, err)
+	_, err = Rasterize(f, nil)
+	must.Error(t, err)
+}
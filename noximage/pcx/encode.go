@@ -0,0 +1,219 @@
+package pcx
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	noxcolor "github.com/noxworld-dev/opennox-lib/color"
+)
+
+// EncodeKind selects how EncodeImage encodes the image.
+type EncodeKind int
+
+const (
+	// EncodeAuto encodes the image as a tile if IsTile returns true for it, or as a sprite otherwise.
+	EncodeAuto = EncodeKind(iota)
+	// EncodeSprite always encodes the image as a sprite.
+	EncodeSprite
+	// EncodeTile always encodes the image as a tile. Pixels outside the tile diamond are ignored.
+	EncodeTile
+)
+
+const spriteType = 3
+
+// EncodeOptions controls conversion of truecolor images to Nox images.
+type EncodeOptions struct {
+	Kind EncodeKind
+	// Palette to quantize colors to, for example DefaultPalette.
+	// If not set, colors are only reduced to 15 bit precision used by the engine.
+	Palette color.Palette
+	// Dither enables Floyd-Steinberg dithering when reducing colors.
+	Dither bool
+	// Point is the sprite draw offset. If not set, the sprite is centered.
+	Point *image.Point
+}
+
+// EncodeImage converts a truecolor image to a Nox sprite or tile and encodes it.
+//
+// Fully transparent pixels are skipped, opaque pixels are stored as RGB555 and semi-transparent ones as RGBA4444.
+// Tiles cannot contain transparent pixels.
+func EncodeImage(img image.Image, opts *EncodeOptions) (*Image, []byte, error) {
+	var o EncodeOptions
+	if opts != nil {
+		o = *opts
+	}
+	pimg := Quantize(img, o.Palette, o.Dither)
+	sz := pimg.Rect.Size()
+	tile := false
+	switch o.Kind {
+	case EncodeAuto:
+		tile = IsTile(pimg)
+	case EncodeTile:
+		if sz.X != tileSize || sz.Y != tileSize {
+			return nil, nil, fmt.Errorf("tile must be %dx%d, got %dx%d", tileSize, tileSize, sz.X, sz.Y)
+		}
+		tile = true
+	case EncodeSprite:
+	default:
+		return nil, nil, fmt.Errorf("unsupported encode kind: %d", o.Kind)
+	}
+	if tile {
+		out := &Image{Image: pimg, ImageMeta: ImageMeta{Type: 0}}
+		return out, EncodeTileImage(out), nil
+	}
+	if sz.X <= 0 || sz.Y <= 0 {
+		return nil, nil, errors.New("empty image")
+	}
+	out := &Image{Image: pimg, ImageMeta: ImageMeta{Type: spriteType}}
+	if o.Point != nil {
+		out.Point = *o.Point
+	} else {
+		out.Point = image.Pt(-sz.X/2, -sz.Y/2)
+	}
+	return out, Encode(out), nil
+}
+
+// tileRows calls fn for each row of the tile diamond with the first pixel offset and the number of pixels.
+func tileRows(fn func(y, x, n int)) {
+	var (
+		dx = tileSize / 2
+		n  = 1
+	)
+	for y := 0; y < tileSize; y++ {
+		fn(y, dx, n)
+		if y < tileSize/2-1 {
+			n += 2
+			dx--
+		} else if y > tileSize/2-1 {
+			n -= 2
+			dx++
+		}
+	}
+}
+
+// IsTile checks if the image can be encoded as a tile without losing pixels:
+// it must have the tile size, all pixels inside the tile diamond must be opaque and all others must be transparent.
+func IsTile(img image.Image) bool {
+	rect := img.Bounds()
+	if rect.Dx() != tileSize || rect.Dy() != tileSize {
+		return false
+	}
+	ok := true
+	tileRows(func(y, x0, n int) {
+		for x := 0; x < tileSize && ok; x++ {
+			_, _, _, a := img.At(rect.Min.X+x, rect.Min.Y+y).RGBA()
+			inside := x >= x0 && x < x0+n
+			if inside && a != 0xffff || !inside && a != 0 {
+				ok = false
+			}
+		}
+	})
+	return ok
+}
+
+// EncodeTileImage encodes the image as a tile. Image must have the tile size, pixels outside the tile diamond are ignored.
+func EncodeTileImage(img *Image) []byte {
+	rgba := asNRGBA(img.Image)
+	data := make([]byte, 0, tileSize*tileSize)
+	var buf [2]byte
+	tileRows(func(y, x0, n int) {
+		for x := x0; x < x0+n; x++ {
+			c := rgba.NRGBAAt(rgba.Rect.Min.X+x, rgba.Rect.Min.Y+y)
+			endiness.PutUint16(buf[:], uint16(noxcolor.RGB5551Color(c.R, c.G, c.B)))
+			data = append(data, buf[:]...)
+		}
+	})
+	return data
+}
+
+// Quantize reduces image colors to a given palette, or to 15 bit colors if palette is nil.
+// Alpha channel is preserved. Fully transparent pixels are not quantized and do not receive dithering error.
+func Quantize(img image.Image, pal color.Palette, dither bool) *image.NRGBA {
+	src := asNRGBA(img)
+	rect := src.Rect
+	dst := image.NewNRGBA(image.Rectangle{Max: rect.Size()})
+	nearest := quantizeRGB555
+	if pal != nil {
+		nearest = newPaletteQuantizer(pal)
+	}
+	w := rect.Dx()
+	// error diffusion buffers for the current and the next row
+	var cur, next [][3]int32
+	if dither {
+		cur = make([][3]int32, w+2)
+		next = make([][3]int32, w+2)
+	}
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < w; x++ {
+			c := src.NRGBAAt(rect.Min.X+x, rect.Min.Y+y)
+			if c.A == 0 {
+				continue
+			}
+			if !dither {
+				q := nearest(c.R, c.G, c.B)
+				q.A = c.A
+				dst.SetNRGBA(x, y, q)
+				continue
+			}
+			e := cur[x+1]
+			r := clamp8(int32(c.R) + e[0]/16)
+			g := clamp8(int32(c.G) + e[1]/16)
+			b := clamp8(int32(c.B) + e[2]/16)
+			q := nearest(r, g, b)
+			q.A = c.A
+			dst.SetNRGBA(x, y, q)
+			diff := [3]int32{int32(r) - int32(q.R), int32(g) - int32(q.G), int32(b) - int32(q.B)}
+			for i, d := range diff {
+				cur[x+2][i] += d * 7
+				next[x][i] += d * 3
+				next[x+1][i] += d * 5
+				next[x+2][i] += d * 1
+			}
+		}
+		if dither {
+			cur, next = next, cur
+			clear(next)
+		}
+	}
+	return dst
+}
+
+func clamp8(v int32) byte {
+	if v < 0 {
+		return 0
+	} else if v > 0xff {
+		return 0xff
+	}
+	return byte(v)
+}
+
+func quantizeRGB555(r, g, b byte) color.NRGBA {
+	return color.NRGBA{R: r & 0xf8, G: g & 0xf8, B: b & 0xf8, A: 0xff}
+}
+
+func newPaletteQuantizer(pal color.Palette) func(r, g, b byte) color.NRGBA {
+	colors := make([]color.NRGBA, len(pal))
+	for i, c := range pal {
+		colors[i] = color.NRGBAModel.Convert(c).(color.NRGBA)
+	}
+	cache := make(map[[3]byte]color.NRGBA)
+	return func(r, g, b byte) color.NRGBA {
+		key := [3]byte{r, g, b}
+		if c, ok := cache[key]; ok {
+			return c
+		}
+		best, bestDist := color.NRGBA{}, int32(-1)
+		for _, c := range colors {
+			dr, dg, db := int32(c.R)-int32(r), int32(c.G)-int32(g), int32(c.B)-int32(b)
+			if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+				best, bestDist = c, d
+			}
+		}
+		// engine stores colors as RGB555
+		best = quantizeRGB555(best.R, best.G, best.B)
+		cache[key] = best
+		return best
+	}
+}
@@ -0,0 +1,133 @@
+package pcx
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/noxtest"
+)
+
+func TestEncodeImageSprite(t *testing.T) {
+	src := noxtest.FillNRGBA(6, 4, color.NRGBA{R: 255, G: 130, B: 7, A: 255})
+	src.SetNRGBA(0, 0, color.NRGBA{})
+	src.SetNRGBA(1, 0, color.NRGBA{R: 255, A: 128})
+
+	img, data, err := EncodeImage(src, nil)
+	must.NoError(t, err)
+	must.EqOp(t, 3, img.Type)
+	must.EqOp(t, image.Pt(-3, -2), img.Point)
+
+	dec, err := Decode(bytes.NewReader(data), img.Type)
+	must.NoError(t, err)
+	must.EqOp(t, img.ImageMeta, dec.ImageMeta)
+	rgba := dec.Image.(*image.NRGBA)
+	must.EqOp(t, color.NRGBA{}, rgba.NRGBAAt(0, 0))
+	must.EqOp(t, color.NRGBA{R: 248, G: 128, A: 255}, rgba.NRGBAAt(5, 3))
+	_, _, _, a := rgba.At(1, 0).RGBA()
+	must.True(t, a > 0 && a < 0xffff)
+
+	pt := image.Pt(1, 2)
+	img, _, err = EncodeImage(src, &EncodeOptions{Point: &pt})
+	must.NoError(t, err)
+	must.EqOp(t, pt, img.Point)
+}
+
+func tileImage(c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	tileRows(func(y, x0, n int) {
+		for x := x0; x < x0+n; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	})
+	return img
+}
+
+func TestEncodeImageTile(t *testing.T) {
+	src := tileImage(color.NRGBA{R: 16, G: 200, B: 96, A: 255})
+	must.True(t, IsTile(src))
+
+	img, data, err := EncodeImage(src, nil)
+	must.NoError(t, err)
+	must.EqOp(t, 0, img.Type)
+	must.Len(t, tileSize*tileSize, data)
+
+	dec, err := Decode(bytes.NewReader(data), img.Type)
+	must.NoError(t, err)
+	must.Eq(t, img.Image.(*image.NRGBA).Pix, dec.Image.(*image.NRGBA).Pix)
+
+	// transparent pixel inside the diamond makes it a sprite
+	src.SetNRGBA(tileSize/2, tileSize/2, color.NRGBA{})
+	must.False(t, IsTile(src))
+	img, _, err = EncodeImage(src, nil)
+	must.NoError(t, err)
+	must.EqOp(t, 3, img.Type)
+
+	img, _, err = EncodeImage(src, &EncodeOptions{Kind: EncodeTile})
+	must.NoError(t, err)
+	must.EqOp(t, 0, img.Type)
+
+	_, _, err = EncodeImage(noxtest.FillNRGBA(4, 4, color.NRGBA{A: 255}), &EncodeOptions{Kind: EncodeTile})
+	must.Error(t, err)
+}
+
+func TestQuantize(t *testing.T) {
+	pal := DefaultPalette()
+	inPal := func(c color.NRGBA) bool {
+		for _, p := range pal {
+			pc := color.NRGBAModel.Convert(p).(color.NRGBA)
+			if quantizeRGB555(pc.R, pc.G, pc.B) == c {
+				return true
+			}
+		}
+		return false
+	}
+	// gradient that cannot be represented exactly
+	src := image.NewNRGBA(image.Rect(0, 0, 32, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 32; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: byte(x * 8), G: byte(y * 30), B: 77, A: 255})
+		}
+	}
+	src.SetNRGBA(0, 0, color.NRGBA{})
+	for _, dither := range []bool{false, true} {
+		dst := Quantize(src, pal, dither)
+		must.EqOp(t, color.NRGBA{}, dst.NRGBAAt(0, 0))
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 32; x++ {
+				if x == 0 && y == 0 {
+					continue
+				}
+				c := dst.NRGBAAt(x, y)
+				must.True(t, inPal(c), must.Sprintf("%d,%d: %v", x, y, c))
+			}
+		}
+	}
+
+	// dithering must preserve the average color better than plain quantization, thus reduce the total error
+	bias := func(img *image.NRGBA) float64 {
+		var sum [3]int
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 32; x++ {
+				if x == 0 && y == 0 {
+					continue
+				}
+				c, o := img.NRGBAAt(x, y), src.NRGBAAt(x, y)
+				sum[0] += int(c.R) - int(o.R)
+				sum[1] += int(c.G) - int(o.G)
+				sum[2] += int(c.B) - int(o.B)
+			}
+		}
+		return math.Abs(float64(sum[0])) + math.Abs(float64(sum[1])) + math.Abs(float64(sum[2]))
+	}
+	plain, dithered := Quantize(src, pal, false), Quantize(src, pal, true)
+	must.NotEq(t, plain.Pix, dithered.Pix)
+	must.Less(t, bias(plain), bias(dithered))
+
+	dst := Quantize(src, nil, false)
+	must.EqOp(t, color.NRGBA{R: 248, G: 208, B: 72, A: 255}, dst.NRGBAAt(31, 7))
+}
@@ -97,8 +97,8 @@ func readSpriteHeader(r io.Reader, typ byte) (*ImageMeta, image.Point, error) {
 	}
 	width := int(endiness.Uint32(b[0:]))
 	height := int(endiness.Uint32(b[4:]))
-	offsX := int(endiness.Uint32(b[8:]))
-	offsY := int(endiness.Uint32(b[12:]))
+	offsX := int(int32(endiness.Uint32(b[8:])))
+	offsY := int(int32(endiness.Uint32(b[12:])))
 	offs := image.Pt(offsX, offsY)
 	// one byte ignored
 	if width <= 0 || width > 1024 || height <= 0 || height > 1024 {
@@ -267,24 +267,13 @@ func readTile(r io.Reader, typ byte) (*Image, error) {
 		Image:     rgba,
 		ImageMeta: ImageMeta{Type: typ},
 	}
-	var (
-		dx = tileSize / 2
-		n  = 1
-	)
-	for y := 0; y < height; y++ {
+	tileRows(func(y, dx, n int) {
 		for x := 0; x < n; x++ {
 			ind := binary.LittleEndian.Uint16(buf[0:2])
 			buf = buf[2:]
 			rgba.SetNRGBA(x+dx, y, noxcolor.RGBA5551(ind).ColorNRGBA())
 		}
-		if y < tileSize/2-1 {
-			n += 2
-			dx--
-		} else if y > tileSize/2-1 {
-			n -= 2
-			dx++
-		}
-	}
+	})
 	return img, nil
 }
 
@@ -0,0 +1,55 @@
+package pcx
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/noxworld-dev/opennox-lib/noximage"
+)
+
+// scalePixel combines color and material index, so that both are scaled consistently.
+type scalePixel struct {
+	c   color.NRGBA
+	mat uint8
+}
+
+// Scale upscales the sprite by an integer factor. Material mask and draw offset are scaled accordingly.
+//
+// Filters never blend pixels, so transparency and material indexes are preserved.
+func Scale(img *Image, factor int, filter noximage.Filter) (*Image, error) {
+	rgba := asNRGBA(img.Image)
+	rect := rgba.Rect
+	sz := rect.Size()
+	pix := make([]scalePixel, sz.X*sz.Y)
+	for y := 0; y < sz.Y; y++ {
+		for x := 0; x < sz.X; x++ {
+			p := scalePixel{c: rgba.NRGBAAt(rect.Min.X+x, rect.Min.Y+y)}
+			if img.Material != nil {
+				p.mat = img.Material.ColorIndexAt(img.Material.Rect.Min.X+x, img.Material.Rect.Min.Y+y)
+			}
+			pix[y*sz.X+x] = p
+		}
+	}
+	pix, err := noximage.ScalePix(pix, sz.X, sz, factor, filter)
+	if err != nil {
+		return nil, err
+	}
+	sz = sz.Mul(factor)
+	dst := image.NewNRGBA(image.Rectangle{Max: sz})
+	var mat *image.Paletted
+	if img.Material != nil {
+		mat = image.NewPaletted(dst.Rect, img.Material.Palette)
+	}
+	for y := 0; y < sz.Y; y++ {
+		for x := 0; x < sz.X; x++ {
+			p := pix[y*sz.X+x]
+			dst.SetNRGBA(x, y, p.c)
+			if mat != nil {
+				mat.SetColorIndex(x, y, p.mat)
+			}
+		}
+	}
+	out := &Image{Image: dst, ImageMeta: img.ImageMeta, Material: mat}
+	out.Point = img.Point.Mul(factor)
+	return out, nil
+}
@@ -0,0 +1,33 @@
+package pcx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/noximage"
+	"github.com/noxworld-dev/opennox-lib/noxtest"
+)
+
+func TestScale(t *testing.T) {
+	src := noxtest.FillNRGBA(2, 2, color.NRGBA{R: 248, A: 255})
+	src.SetNRGBA(1, 1, color.NRGBA{})
+	mat := image.NewPaletted(src.Rect, PlaceholderPalette())
+	mat.SetColorIndex(0, 1, 3)
+	img := &Image{Image: src, ImageMeta: ImageMeta{Type: 3, Point: image.Pt(-1, -2)}, Material: mat}
+
+	out, err := Scale(img, 2, noximage.FilterScaleX)
+	must.NoError(t, err)
+	must.EqOp(t, image.Pt(-2, -4), out.Point)
+	must.EqOp(t, image.Rect(0, 0, 4, 4), out.Bounds())
+	rgba := out.Image.(*image.NRGBA)
+	must.EqOp(t, color.NRGBA{R: 248, A: 255}, rgba.NRGBAAt(0, 0))
+	must.EqOp(t, color.NRGBA{}, rgba.NRGBAAt(3, 3))
+	must.EqOp(t, 3, out.Material.ColorIndexAt(0, 3))
+	must.EqOp(t, 0, out.Material.ColorIndexAt(0, 0))
+
+	_, err = Scale(img, 5, noximage.FilterScaleX)
+	must.Error(t, err)
+}
@@ -0,0 +1,197 @@
+package noximage
+
+import (
+	"fmt"
+	"image"
+)
+
+// Filter is a pixel-art upscaling filter.
+type Filter int
+
+const (
+	// FilterNearest is a nearest-neighbour filter. It supports any integer scale factor.
+	FilterNearest = Filter(iota)
+	// FilterScaleX is an edge-preserving EPX filter (Scale2x/Scale3x, also known as AdvMAME2x/AdvMAME3x).
+	// It supports factors that are products of 2 and 3 (2, 3, 4, 6, 8, 9, ...).
+	//
+	// Unlike hqx or xBR, filter never blends colors, thus it preserves the palette and transparency of the sprite.
+	FilterScaleX
+)
+
+// ParseFilter parses filter name, as returned by Filter.String.
+func ParseFilter(s string) (Filter, error) {
+	switch s {
+	case "nearest":
+		return FilterNearest, nil
+	case "scalex":
+		return FilterScaleX, nil
+	}
+	return 0, fmt.Errorf("unsupported filter: %q", s)
+}
+
+func (f Filter) String() string {
+	switch f {
+	case FilterNearest:
+		return "nearest"
+	case FilterScaleX:
+		return "scalex"
+	}
+	return fmt.Sprintf("Filter(%d)", int(f))
+}
+
+// Scale16 upscales the image by an integer factor. Pixels are copied as-is, including the RGBA5551 transparency bit.
+func Scale16(img *Image16, factor int, filter Filter) (*Image16, error) {
+	sz := img.Rect.Size()
+	pix, err := ScalePix(img.Pix, img.Stride, sz, factor, filter)
+	if err != nil {
+		return nil, err
+	}
+	return NewImage16WithData(pix, sz.Mul(factor)), nil
+}
+
+// ScalePix upscales pixels of an image with a given size by an integer factor.
+// It returns a new pixel slice with stride equal to the new width.
+//
+// Pixels are never blended, only compared for equality, thus any pixel type can be used.
+func ScalePix[T comparable](pix []T, stride int, sz image.Point, factor int, filter Filter) ([]T, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("invalid scale factor: %d", factor)
+	}
+	switch filter {
+	case FilterNearest:
+		return scaleNearest(pix, stride, sz, factor), nil
+	case FilterScaleX:
+		var steps []int
+		for f := factor; f > 1; {
+			switch {
+			case f%2 == 0:
+				steps = append(steps, 2)
+				f /= 2
+			case f%3 == 0:
+				steps = append(steps, 3)
+				f /= 3
+			default:
+				return nil, fmt.Errorf("scale factor %d is not supported by %v filter", factor, filter)
+			}
+		}
+		if len(steps) == 0 {
+			return scaleNearest(pix, stride, sz, 1), nil
+		}
+		for _, f := range steps {
+			if f == 2 {
+				pix = scale2x(pix, stride, sz)
+			} else {
+				pix = scale3x(pix, stride, sz)
+			}
+			sz = sz.Mul(f)
+			stride = sz.X
+		}
+		return pix, nil
+	}
+	return nil, fmt.Errorf("unsupported filter: %v", filter)
+}
+
+func scaleNearest[T any](pix []T, stride int, sz image.Point, factor int) []T {
+	w := sz.X * factor
+	out := make([]T, w*sz.Y*factor)
+	for y := 0; y < sz.Y; y++ {
+		row := out[y*factor*w : (y*factor+1)*w]
+		for x, p := range pix[y*stride : y*stride+sz.X] {
+			for i := 0; i < factor; i++ {
+				row[x*factor+i] = p
+			}
+		}
+		for i := 1; i < factor; i++ {
+			copy(out[(y*factor+i)*w:], row)
+		}
+	}
+	return out
+}
+
+// neighbours returns a 3x3 block of pixels around (x, y), clamping coordinates at the image edges.
+//
+//	A B C
+//	D E F
+//	G H I
+func neighbours[T any](pix []T, stride int, sz image.Point, x, y int) (a, b, c, d, e, f, g, h, i T) {
+	xl, xr := max(x-1, 0), min(x+1, sz.X-1)
+	yu, yd := max(y-1, 0), min(y+1, sz.Y-1)
+	up, row, down := pix[yu*stride:], pix[y*stride:], pix[yd*stride:]
+	return up[xl], up[x], up[xr],
+		row[xl], row[x], row[xr],
+		down[xl], down[x], down[xr]
+}
+
+func scale2x[T comparable](pix []T, stride int, sz image.Point) []T {
+	w := 2 * sz.X
+	out := make([]T, w*2*sz.Y)
+	for y := 0; y < sz.Y; y++ {
+		r0, r1 := out[2*y*w:], out[(2*y+1)*w:]
+		for x := 0; x < sz.X; x++ {
+			_, b, _, d, e, f, _, h, _ := neighbours(pix, stride, sz, x, y)
+			e0, e1, e2, e3 := e, e, e, e
+			if b != h && d != f {
+				if d == b {
+					e0 = d
+				}
+				if b == f {
+					e1 = f
+				}
+				if d == h {
+					e2 = d
+				}
+				if h == f {
+					e3 = f
+				}
+			}
+			r0[2*x], r0[2*x+1] = e0, e1
+			r1[2*x], r1[2*x+1] = e2, e3
+		}
+	}
+	return out
+}
+
+func scale3x[T comparable](pix []T, stride int, sz image.Point) []T {
+	w := 3 * sz.X
+	out := make([]T, w*3*sz.Y)
+	for y := 0; y < sz.Y; y++ {
+		r0, r1, r2 := out[3*y*w:], out[(3*y+1)*w:], out[(3*y+2)*w:]
+		for x := 0; x < sz.X; x++ {
+			a, b, c, d, e, f, g, h, i := neighbours(pix, stride, sz, x, y)
+			var o [9]T
+			for j := range o {
+				o[j] = e
+			}
+			if b != h && d != f {
+				if d == b {
+					o[0] = d
+				}
+				if (d == b && e != c) || (b == f && e != a) {
+					o[1] = b
+				}
+				if b == f {
+					o[2] = f
+				}
+				if (d == b && e != g) || (d == h && e != a) {
+					o[3] = d
+				}
+				if (b == f && e != i) || (h == f && e != c) {
+					o[5] = f
+				}
+				if d == h {
+					o[6] = d
+				}
+				if (d == h && e != i) || (h == f && e != g) {
+					o[7] = h
+				}
+				if h == f {
+					o[8] = f
+				}
+			}
+			copy(r0[3*x:], o[0:3])
+			copy(r1[3*x:], o[3:6])
+			copy(r2[3*x:], o[6:9])
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package noximage
+
+import (
+	"image"
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	noxcolor "github.com/noxworld-dev/opennox-lib/color"
+)
+
+const (
+	tr = uint16(noxcolor.TransparentRGBA5551)
+	cr = 0x7c00 // red
+)
+
+func TestScale16Nearest(t *testing.T) {
+	img := NewImage16WithData([]uint16{
+		tr, cr,
+		cr, 1,
+	}, image.Pt(2, 2))
+	out, err := Scale16(img, 3, FilterNearest)
+	must.NoError(t, err)
+	must.EqOp(t, image.Rect(0, 0, 6, 6), out.Rect)
+	must.Eq(t, []uint16{
+		tr, tr, tr, cr, cr, cr,
+		tr, tr, tr, cr, cr, cr,
+		tr, tr, tr, cr, cr, cr,
+		cr, cr, cr, 1, 1, 1,
+		cr, cr, cr, 1, 1, 1,
+		cr, cr, cr, 1, 1, 1,
+	}, out.Pix)
+}
+
+func TestScale16ScaleX(t *testing.T) {
+	// diagonal line must stay thin instead of becoming a staircase of 2x2 blocks
+	img := NewImage16WithData([]uint16{
+		cr, tr, tr,
+		tr, cr, tr,
+		tr, tr, cr,
+	}, image.Pt(3, 3))
+	out, err := Scale16(img, 2, FilterScaleX)
+	must.NoError(t, err)
+	must.Eq(t, []uint16{
+		cr, cr, tr, tr, tr, tr,
+		cr, tr, cr, tr, tr, tr,
+		tr, cr, cr, cr, tr, tr,
+		tr, tr, cr, cr, cr, tr,
+		tr, tr, tr, cr, tr, cr,
+		tr, tr, tr, tr, cr, cr,
+	}, out.Pix)
+
+	out, err = Scale16(img, 3, FilterScaleX)
+	must.NoError(t, err)
+	must.EqOp(t, image.Rect(0, 0, 9, 9), out.Rect)
+	for _, p := range out.Pix {
+		must.True(t, p == cr || p == tr)
+	}
+
+	out, err = Scale16(img, 6, FilterScaleX)
+	must.NoError(t, err)
+	must.EqOp(t, image.Rect(0, 0, 18, 18), out.Rect)
+
+	_, err = Scale16(img, 5, FilterScaleX)
+	must.Error(t, err)
+	_, err = Scale16(img, 0, FilterNearest)
+	must.Error(t, err)
+}
+
+func TestScale16SubImage(t *testing.T) {
+	img := NewImage16WithData([]uint16{
+		1, 2, 3,
+		4, 5, 6,
+	}, image.Pt(3, 2))
+	sub := img.SubImage(image.Rect(1, 0, 3, 2))
+	out, err := Scale16(sub, 2, FilterNearest)
+	must.NoError(t, err)
+	must.Eq(t, []uint16{
+		2, 2, 3, 3,
+		2, 2, 3, 3,
+		5, 5, 6, 6,
+		5, 5, 6, 6,
+	}, out.Pix)
+}
+
+func TestParseFilter(t *testing.T) {
+	for _, f := range []Filter{FilterNearest, FilterScaleX} {
+		f2, err := ParseFilter(f.String())
+		must.NoError(t, err)
+		must.EqOp(t, f, f2)
+	}
+	_, err := ParseFilter("bicubic")
+	must.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"image"
+	"image/color"
 	"image/png"
 	"io"
 	"os"
@@ -37,3 +38,14 @@ func WritePNG(t testing.TB, path string, img image.Image, exp string) {
 		t.Logf("%s: %s", path, got)
 	}
 }
+
+// FillNRGBA creates an image of a given size, filled with a single color.
+func FillNRGBA(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
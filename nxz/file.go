@@ -0,0 +1,53 @@
+package nxz
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// ReadFile reads and decompresses an .nxz file.
+//
+// Such files start with a 32-bit decompressed size, followed by the compressed stream.
+func ReadFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	var hdr [4]byte
+	if _, err = io.ReadFull(br, hdr[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(hdr[:]))
+	if _, err = io.ReadFull(NewReader(br), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// WriteFile compresses data and writes it as an .nxz file. See ReadFile.
+func WriteFile(path string, data []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+	var hdr [4]byte
+	binary.LittleEndian.PutUint32(hdr[:], uint32(len(data)))
+	bw.Write(hdr[:])
+	w := NewWriter(bw)
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -1,18 +1,21 @@
 package nxz
 
 import (
-	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
-	"sort"
-
-	"github.com/icza/bitio"
+	"slices"
 )
 
 const (
 	symbols    = 274
 	windowSize = 0x10000
 	rebuildCnt = 8192
+
+	tableBits = 12 // bits in the symbol lookup table
+	tableMask = 1<<tableBits - 1
+	winMask   = windowSize - 1
 )
 
 var lenTable = [][2]int{
@@ -56,178 +59,309 @@ var initialInd = [][2]int{
 	{0x05, 0xF4},
 }
 
+// Reader decompresses nxz data.
 type Reader struct {
-	br   *bitio.Reader
-	buf  bytes.Buffer
-	sym  [symbols]int
-	ind  [symbols][2]int
-	cnt  [symbols]int
-	win  [windowSize]int
-	wpos uint
+	r    io.Reader
+	in   [4096]byte
+	ip   int // read position in the input buffer
+	iend int
+	eof  bool
+
+	acc uint64 // bit buffer, next bit is at position n-1
+	n   uint   // number of bits in the buffer
+
+	sym [symbols]int // rank -> symbol
+	ind [16][2]int   // bits and rank offset of each code
+	cnt [symbols]int
+	// tab maps tableBits of input to a symbol (low 16 bits) and code length (high bits).
+	// Zero length means that the code is longer than tableBits, or refers to an invalid rank.
+	tab [1 << tableBits]uint32
+
+	win  [windowSize]byte
+	wpos uint32
+	mlen int    // bytes left to copy for the current match
+	msrc uint32 // window position to copy the match from
 	err  error
 }
 
 func NewReader(r io.Reader) *Reader {
-	rd := &Reader{
-		br: bitio.NewReader(r),
-	}
+	rd := &Reader{r: r}
 	rd.buildInitial()
 	return rd
 }
 
 func (r *Reader) buildInitial() {
 	copy(r.ind[:], initialInd)
+	buildInitial(&r.sym)
+	r.buildTable()
+}
+
+// buildInitial fills the initial symbol table, ordered by rank.
+func buildInitial(sym *[symbols]int) {
 	seen := map[int]struct{}{
 		0: {}, 0x20: {}, 0x30: {}, 0xff: {},
 	}
 	pos := 0
 	for ; pos < 16; pos++ {
-		r.sym[pos] = pos + 0x100
-		seen[r.sym[pos]] = struct{}{}
+		sym[pos] = pos + 0x100
+		seen[sym[pos]] = struct{}{}
 	}
-	r.sym[pos] = 0
+	sym[pos] = 0
 	pos++
-	r.sym[pos] = 0x20
+	sym[pos] = 0x20
 	pos++
-	r.sym[pos] = 0x30
+	sym[pos] = 0x30
 	pos++
-	r.sym[pos] = 0xff
+	sym[pos] = 0xff
 	pos++
 	for i := 1; i <= 0x111; i++ {
 		if _, ok := seen[i]; !ok {
-			r.sym[pos] = i
+			sym[pos] = i
 			pos++
 		}
 	}
 }
 
 func (r *Reader) rebuild() {
-	huff := make([]int, symbols)
-	for i := range huff {
-		huff[i] = i
-	}
-	sort.Slice(huff, func(i, j int) bool {
-		x1, x2 := huff[i], huff[j]
-		return ((r.cnt[x2]<<16)+x2)-((r.cnt[x1]<<16)+x1) < 0
-	})
-	copy(r.sym[:], huff)
+	rebuildSymbols(&r.sym, &r.cnt)
 }
 
-func (r *Reader) readBits(n byte) (uint64, error) {
-	if n == 0 {
-		return 0, nil
+// rebuildSymbols orders symbols by their counts, most frequent first. Ties are ordered by the symbol value, descending.
+func rebuildSymbols(sym *[symbols]int, cnt *[symbols]int) {
+	var keys [symbols]uint64
+	for i := range keys {
+		keys[i] = uint64(cnt[i])<<16 | uint64(i)
+	}
+	slices.Sort(keys[:])
+	for i, k := range keys {
+		sym[symbols-1-i] = int(k & 0xffff)
 	}
-	return r.br.ReadBits(n)
 }
 
-func (r *Reader) decodeMore() {
-	for r.buf.Len() < 256 {
-		code, err := r.readBits(4)
-		if err != nil {
-			r.err = err
-			return
-		}
-		bits := r.ind[code][0]
-		offs := r.ind[code][1]
-		ind, err := r.readBits(byte(bits))
-		if err != nil {
-			r.err = err
-			return
-		}
-		ind += uint64(offs)
-		if int(ind) >= len(r.sym) {
-			r.err = fmt.Errorf("nxz: invalid index: %d vs %d", int(ind), len(r.sym))
-			return
-		}
-		sym := r.sym[ind]
-		r.cnt[sym]++
-		if sym < 0x100 {
-			r.buf.WriteByte(byte(sym))
-			r.win[r.wpos%windowSize] = sym
-			r.wpos++
+// buildTable fills the lookup table from the current code table and symbol ranks.
+func (r *Reader) buildTable() {
+	for code, v := range r.ind {
+		bits, offs := v[0], v[1]
+		pref := code << (tableBits - 4)
+		l := 4 + bits
+		if l > tableBits {
+			clear(r.tab[pref : pref+1<<(tableBits-4)])
 			continue
 		}
-		if sym == 0x110 {
-			r.rebuild()
-			for i := range r.cnt {
-				r.cnt[i] /= 2
+		fill := 1 << (tableBits - l)
+		for i := 0; i < 1<<bits; i++ {
+			var e uint32
+			if rank := offs + i; rank < symbols {
+				e = uint32(l)<<16 | uint32(r.sym[rank])
+			}
+			start := pref + i*fill
+			for j := start; j < start+fill; j++ {
+				r.tab[j] = e
 			}
-			bits = 0
-			offs = 0
-			for i := 0; i < 16; i++ {
-				for {
-					b, err := r.br.ReadBool()
-					if err != nil {
-						r.err = err
-						return
-					} else if b {
-						break
-					}
-					bits++
+		}
+	}
+}
+
+// refill the bit buffer, so it contains at least 56 bits, unless the input ends.
+func (r *Reader) refill() {
+	if r.iend-r.ip >= 8 {
+		// fast path: load as many whole bytes as fit
+		k := (64 - r.n) / 8
+		v := binary.BigEndian.Uint64(r.in[r.ip:])
+		r.acc = r.acc<<(8*k) | v>>(64-8*k)
+		r.ip += int(k)
+		r.n += 8 * k
+		return
+	}
+	for r.n <= 56 {
+		if r.ip == r.iend {
+			if r.eof {
+				return
+			}
+			n, err := io.ReadAtLeast(r.r, r.in[:], 1)
+			r.ip, r.iend = 0, n
+			if err != nil {
+				r.eof = true
+				if err != io.EOF && err != io.ErrUnexpectedEOF {
+					r.err = err
+				}
+				if n == 0 {
+					return
 				}
-				r.ind[i][0] = bits
-				r.ind[i][1] = offs
-				offs += 1 << bits
 			}
-			continue
 		}
-		if sym >= 0x111 {
-			continue // TODO: a workaround? or is it an end opcode?
+		r.acc = r.acc<<8 | uint64(r.in[r.ip])
+		r.ip++
+		r.n += 8
+	}
+}
+
+func (r *Reader) readBits(n uint) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	if r.n < n {
+		r.refill()
+		if r.n < n {
+			if r.err != nil {
+				return 0, r.err
+			}
+			return 0, io.EOF
 		}
-		// LZ77
-		leng := uint64(4)
-		if sym < 0x108 {
-			leng += uint64(sym - 0x100)
-		} else {
-			ind = uint64(sym - 0x108)
-			bits = lenTable[ind][0]
-			offs = lenTable[ind][1]
-			b, err := r.readBits(byte(bits))
+	}
+	r.n -= n
+	return (r.acc >> r.n) & (1<<n - 1), nil
+}
+
+func (r *Reader) readSym() (int, error) {
+	if r.n < tableBits {
+		r.refill()
+	}
+	if r.n >= tableBits {
+		e := r.tab[(r.acc>>(r.n-tableBits))&tableMask]
+		if l := uint(e >> 16); l != 0 {
+			r.n -= l
+			return int(e & 0xffff), nil
+		}
+	}
+	// slow path: long codes, invalid ranks or the end of the stream
+	code, err := r.readBits(4)
+	if err != nil {
+		return 0, err
+	}
+	bits, offs := r.ind[code][0], r.ind[code][1]
+	ind, err := r.readBits(uint(bits))
+	if err != nil {
+		return 0, err
+	}
+	ind += uint64(offs)
+	if ind >= symbols {
+		return 0, fmt.Errorf("nxz: invalid index: %d vs %d", int(ind), symbols)
+	}
+	return r.sym[ind], nil
+}
+
+// readCodeTable reads new bit sizes for all codes. Sizes are encoded as unary deltas.
+func (r *Reader) readCodeTable() error {
+	bits, offs := 0, 0
+	for i := range r.ind {
+		for {
+			b, err := r.readBits(1)
 			if err != nil {
-				r.err = err
-				return
+				return err
+			} else if b != 0 {
+				break
+			}
+			bits++
+			if bits > 32 {
+				return errors.New("nxz: invalid code table")
 			}
-			leng += uint64(offs) + b
 		}
+		r.ind[i][0] = bits
+		r.ind[i][1] = offs
+		offs += 1 << bits
+	}
+	return nil
+}
 
-		code, err = r.readBits(3)
-		if err != nil {
+// decode a single symbol. Decoded data is copied to the output by Read. Errors are stored in r.err.
+func (r *Reader) decode() {
+	sym, err := r.readSym()
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.cnt[sym]++
+	switch {
+	case sym < 0x100:
+		// copy the literal to the output the same way as a single byte match
+		r.win[r.wpos&winMask] = byte(sym)
+		r.msrc = r.wpos
+		r.mlen = 1
+		return
+	case sym == 0x110:
+		r.rebuild()
+		for i := range r.cnt {
+			r.cnt[i] /= 2
+		}
+		if err = r.readCodeTable(); err != nil {
 			r.err = err
 			return
 		}
-		bits = distTable[code][0]
-		offs = distTable[code][1]
-		b, err := r.readBits(byte(bits) + 9)
+		r.buildTable()
+		return
+	case sym >= 0x111:
+		return // TODO: a workaround? or is it an end opcode?
+	}
+	// LZ77
+	leng := 4
+	if sym < 0x108 {
+		leng += sym - 0x100
+	} else {
+		v := lenTable[sym-0x108]
+		b, err := r.readBits(uint(v[0]))
 		if err != nil {
 			r.err = err
 			return
 		}
-		dist := (uint64(offs) << 9) + b
-		ind = uint64(r.wpos) - dist
-		for i := uint64(0); i < leng; i++ {
-			sym = r.win[(ind+i)%windowSize]
-			r.buf.WriteByte(byte(sym))
-			r.win[r.wpos%windowSize] = sym
-			r.wpos++
-		}
+		leng += v[1] + int(b)
 	}
+	code, err := r.readBits(3)
+	if err != nil {
+		r.err = err
+		return
+	}
+	v := distTable[code]
+	b, err := r.readBits(uint(v[0]) + 9)
+	if err != nil {
+		r.err = err
+		return
+	}
+	dist := uint32(v[1])<<9 + uint32(b)
+	r.msrc = r.wpos - dist
+	r.mlen = leng
 }
 
 func (r *Reader) Read(p []byte) (int, error) {
 	total := 0
-	for len(p) > 0 {
-		n, err := r.buf.Read(p)
-		p = p[n:]
-		total += n
-		if err == io.EOF {
-			if r.err != nil {
-				return total, r.err
+	for total < len(p) {
+		if r.mlen > 0 {
+			n := r.mlen
+			if rest := len(p) - total; n > rest {
+				n = rest
+			}
+			for i := 0; i < n; i++ {
+				c := r.win[r.msrc&winMask]
+				r.win[r.wpos&winMask] = c
+				p[total+i] = c
+				r.msrc++
+				r.wpos++
 			}
-			r.decodeMore()
+			r.mlen -= n
+			total += n
 			continue
-		} else if err != nil {
-			return total, err
 		}
+		if r.err != nil {
+			return total, r.err
+		}
+		if r.n < tableBits {
+			r.refill()
+		}
+		if r.n >= tableBits {
+			e := r.tab[(r.acc>>(r.n-tableBits))&tableMask]
+			if l := uint(e >> 16); l != 0 && e&0xffff < 0x100 {
+				// fast path for literals
+				c := byte(e)
+				r.n -= l
+				r.cnt[c]++
+				r.win[r.wpos&winMask] = c
+				r.wpos++
+				p[total] = c
+				total++
+				continue
+			}
+		}
+		r.decode()
 	}
 	return total, nil
 }
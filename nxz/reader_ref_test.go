@@ -0,0 +1,166 @@
+package nxz
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/icza/bitio"
+)
+
+// refReader is the original bit-by-bit nxz decoder. It is used as a reference implementation in tests.
+type refReader struct {
+	br   *bitio.Reader
+	buf  bytes.Buffer
+	sym  [symbols]int
+	ind  [symbols][2]int
+	cnt  [symbols]int
+	win  [windowSize]int
+	wpos uint
+	err  error
+}
+
+func newRefReader(r io.Reader) *refReader {
+	rd := &refReader{
+		br: bitio.NewReader(r),
+	}
+	rd.buildInitial()
+	return rd
+}
+
+func (r *refReader) buildInitial() {
+	copy(r.ind[:], initialInd)
+	buildInitial(&r.sym)
+}
+
+func (r *refReader) rebuild() {
+	huff := make([]int, symbols)
+	for i := range huff {
+		huff[i] = i
+	}
+	sort.Slice(huff, func(i, j int) bool {
+		x1, x2 := huff[i], huff[j]
+		return ((r.cnt[x2]<<16)+x2)-((r.cnt[x1]<<16)+x1) < 0
+	})
+	copy(r.sym[:], huff)
+}
+
+func (r *refReader) readBits(n byte) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	return r.br.ReadBits(n)
+}
+
+func (r *refReader) decodeMore() {
+	for r.buf.Len() < 256 {
+		code, err := r.readBits(4)
+		if err != nil {
+			r.err = err
+			return
+		}
+		bits := r.ind[code][0]
+		offs := r.ind[code][1]
+		ind, err := r.readBits(byte(bits))
+		if err != nil {
+			r.err = err
+			return
+		}
+		ind += uint64(offs)
+		if int(ind) >= len(r.sym) {
+			r.err = fmt.Errorf("nxz: invalid index: %d vs %d", int(ind), len(r.sym))
+			return
+		}
+		sym := r.sym[ind]
+		r.cnt[sym]++
+		if sym < 0x100 {
+			r.buf.WriteByte(byte(sym))
+			r.win[r.wpos%windowSize] = sym
+			r.wpos++
+			continue
+		}
+		if sym == 0x110 {
+			r.rebuild()
+			for i := range r.cnt {
+				r.cnt[i] /= 2
+			}
+			bits = 0
+			offs = 0
+			for i := 0; i < 16; i++ {
+				for {
+					b, err := r.br.ReadBool()
+					if err != nil {
+						r.err = err
+						return
+					} else if b {
+						break
+					}
+					bits++
+				}
+				r.ind[i][0] = bits
+				r.ind[i][1] = offs
+				offs += 1 << bits
+			}
+			continue
+		}
+		if sym >= 0x111 {
+			continue // TODO: a workaround? or is it an end opcode?
+		}
+		// LZ77
+		leng := uint64(4)
+		if sym < 0x108 {
+			leng += uint64(sym - 0x100)
+		} else {
+			ind = uint64(sym - 0x108)
+			bits = lenTable[ind][0]
+			offs = lenTable[ind][1]
+			b, err := r.readBits(byte(bits))
+			if err != nil {
+				r.err = err
+				return
+			}
+			leng += uint64(offs) + b
+		}
+
+		code, err = r.readBits(3)
+		if err != nil {
+			r.err = err
+			return
+		}
+		bits = distTable[code][0]
+		offs = distTable[code][1]
+		b, err := r.readBits(byte(bits) + 9)
+		if err != nil {
+			r.err = err
+			return
+		}
+		dist := (uint64(offs) << 9) + b
+		ind = uint64(r.wpos) - dist
+		for i := uint64(0); i < leng; i++ {
+			sym = r.win[(ind+i)%windowSize]
+			r.buf.WriteByte(byte(sym))
+			r.win[r.wpos%windowSize] = sym
+			r.wpos++
+		}
+	}
+}
+
+func (r *refReader) Read(p []byte) (int, error) {
+	total := 0
+	for len(p) > 0 {
+		n, err := r.buf.Read(p)
+		p = p[n:]
+		total += n
+		if err == io.EOF {
+			if r.err != nil {
+				return total, r.err
+			}
+			r.decodeMore()
+			continue
+		} else if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
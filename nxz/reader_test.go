@@ -0,0 +1,146 @@
+package nxz
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func testData(n int) []byte {
+	rnd := rand.New(rand.NewSource(1))
+	words := strings.Fields("the quick brown fox jumps over a lazy dog and runs away from the castle")
+	var buf bytes.Buffer
+	for buf.Len() < n {
+		switch rnd.Intn(3) {
+		case 0:
+			var b [16]byte
+			rnd.Read(b[:])
+			buf.Write(b[:rnd.Intn(len(b))])
+		default:
+			buf.WriteString(words[rnd.Intn(len(words))])
+			buf.WriteByte(' ')
+		}
+	}
+	return buf.Bytes()[:n]
+}
+
+// testSkewedData returns text with rare random bytes, which get long codes after the first rebuild.
+func testSkewedData(n int) []byte {
+	rnd := rand.New(rand.NewSource(3))
+	data := []byte(strings.Repeat("abcdefgh", n/8))
+	for i := range data {
+		data[i] = data[rnd.Intn(len(data))]
+		if rnd.Intn(500) == 0 {
+			data[i] = byte(rnd.Intn(256))
+		}
+	}
+	return data
+}
+
+func readAllLimit(r io.Reader) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(r, 4<<20))
+}
+
+func TestReaderRef(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	var inputs [][]byte
+	for _, data := range [][]byte{
+		testData(1000),
+		testData(300000),
+		make([]byte, 100000),
+		testSkewedData(200000),
+	} {
+		comp, err := Compress(data)
+		must.NoError(t, err)
+		inputs = append(inputs, comp)
+	}
+	// random streams exercise invalid indexes, long codes and unexpected end of the stream
+	for i := 0; i < 50; i++ {
+		b := make([]byte, rnd.Intn(2000))
+		rnd.Read(b)
+		inputs = append(inputs, b)
+	}
+	for _, in := range inputs {
+		exp, err1 := readAllLimit(newRefReader(bytes.NewReader(in)))
+		got, err2 := readAllLimit(NewReader(bytes.NewReader(in)))
+		must.True(t, bytes.Equal(exp, got))
+		must.EqOp(t, err1 == nil, err2 == nil)
+	}
+}
+
+func TestReaderSmallReads(t *testing.T) {
+	data := testData(100000)
+	comp, err := Compress(data)
+	must.NoError(t, err)
+	r := NewReader(bytes.NewReader(comp))
+	got := make([]byte, 0, len(data))
+	var buf [7]byte
+	for len(got) < len(data) {
+		n, err := r.Read(buf[:])
+		got = append(got, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		must.NoError(t, err)
+	}
+	must.True(t, bytes.Equal(data, got[:len(data)]))
+}
+
+func benchmarkReader(b *testing.B, newReader func(r io.Reader) io.Reader) {
+	data := testData(1 << 20)
+	comp, err := Compress(data)
+	must.NoError(b, err)
+	out := make([]byte, len(data))
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = io.ReadFull(newReader(bytes.NewReader(comp)), out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	benchmarkReader(b, func(r io.Reader) io.Reader { return NewReader(r) })
+}
+
+func BenchmarkReaderRef(b *testing.B) {
+	benchmarkReader(b, func(r io.Reader) io.Reader { return newRefReader(r) })
+}
+
+func TestReaderLongCodes(t *testing.T) {
+	// build a stream with a code table that doesn't fit into the lookup table
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.writeSym(0x110)
+	rebuildSymbols(&w.sym, &w.cnt)
+	w.updateRanks()
+	for i := range w.cnt {
+		w.cnt[i] /= 2
+	}
+	w.writeBits(0, 10)
+	for i := range w.ind {
+		w.writeBits(1, 1)
+		w.ind[i] = [2]int{10, i << 10}
+	}
+	for _, c := range []byte("long codes") {
+		w.writeSym(int(c))
+	}
+	w.writeMatch(5, 5)
+	must.NoError(t, w.bw.Close())
+	must.NoError(t, w.err)
+
+	exp := []byte("long codescodes")
+	got := make([]byte, len(exp))
+	_, err := io.ReadFull(NewReader(bytes.NewReader(buf.Bytes())), got)
+	must.NoError(t, err)
+	must.Eq(t, exp, got)
+	_, err = io.ReadFull(newRefReader(bytes.NewReader(buf.Bytes())), got)
+	must.NoError(t, err)
+	must.Eq(t, exp, got)
+}
@@ -0,0 +1,324 @@
+package nxz
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/icza/bitio"
+)
+
+const (
+	minMatch   = 4
+	maxMatch   = minMatch + 0x106 + 0xff // see lenTable
+	maxDist    = windowSize - 1
+	hashBits   = 15
+	maxChain   = 64
+	writeBlock = 4 * windowSize // amount of pending data that triggers encoding
+)
+
+// Writer compresses data in nxz format. It must be closed to flush all the data.
+//
+// The format has no end marker, thus the decompressed size must be stored separately.
+type Writer struct {
+	bw  *bitio.Writer
+	err error
+
+	sym  [symbols]int // rank -> symbol
+	rank [symbols]int // symbol -> rank
+	ind  [16][2]int
+	cnt  [symbols]int
+	nsym int // symbols written since the last rebuild
+
+	buf  []byte // window and pending data
+	base int    // absolute position of buf[0]
+	pos  int    // absolute position of the first byte that is not encoded yet
+	head [1 << hashBits]int
+	prev [windowSize]int
+}
+
+// NewWriter creates a new nxz compressor.
+func NewWriter(w io.Writer) *Writer {
+	wr := &Writer{bw: bitio.NewWriter(w)}
+	buildInitial(&wr.sym)
+	copy(wr.ind[:], initialInd)
+	wr.updateRanks()
+	for i := range wr.head {
+		wr.head[i] = -1
+	}
+	return wr
+}
+
+// Compress data in nxz format.
+func Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *Writer) updateRanks() {
+	for i, s := range w.sym {
+		w.rank[s] = i
+	}
+}
+
+func (w *Writer) writeBits(v uint64, n int) {
+	if w.err != nil || n == 0 {
+		return
+	}
+	w.err = w.bw.WriteBits(v, uint8(n))
+}
+
+func (w *Writer) writeSym(sym int) {
+	r := w.rank[sym]
+	for code := 0; code < 16; code++ {
+		bits, offs := w.ind[code][0], w.ind[code][1]
+		if r >= offs && r < offs+1<<bits {
+			w.writeBits(uint64(code), 4)
+			w.writeBits(uint64(r-offs), bits)
+			break
+		}
+	}
+	w.cnt[sym]++
+	w.nsym++
+}
+
+// rebuild writes the rebuild symbol and a new code table, the same way Reader expects it.
+func (w *Writer) rebuild() {
+	w.writeSym(0x110)
+	w.nsym = 0
+	rebuildSymbols(&w.sym, &w.cnt)
+	w.updateRanks()
+	var freq [symbols]int
+	for i, s := range w.sym {
+		freq[i] = w.cnt[s]
+	}
+	for i := range w.cnt {
+		w.cnt[i] /= 2
+	}
+	bits := optimalGroups(&freq)
+	last, offs := 0, 0
+	for i, b := range bits {
+		for ; last < b; last++ {
+			w.writeBits(0, 1)
+		}
+		w.writeBits(1, 1)
+		w.ind[i][0] = b
+		w.ind[i][1] = offs
+		offs += 1 << b
+	}
+}
+
+// optimalGroups selects bit sizes for 16 index groups, given symbol frequencies sorted by rank.
+// Bit sizes must not decrease, and groups must cover all symbols.
+func optimalGroups(freq *[symbols]int) [16]int {
+	const maxBits = 9 // enough to cover all symbols with one group
+	var sum [symbols + 1]int
+	for i, f := range freq {
+		sum[i+1] = sum[i] + f
+	}
+	const inf = int(^uint(0) >> 1)
+	type state struct {
+		cost int
+		prev int // position before the group
+	}
+	// dp[g][p][b] is the cost of covering p ranks with g groups, the last one having b bits
+	var dp [17][symbols + 1][maxBits + 1]state
+	for g := range dp {
+		for p := range dp[g] {
+			for b := range dp[g][p] {
+				dp[g][p][b].cost = inf
+			}
+		}
+	}
+	dp[0][0][0].cost = 0
+	for g := 0; g < 16; g++ {
+		for p := 0; p <= symbols; p++ {
+			for b := 0; b <= maxBits; b++ {
+				cur := dp[g][p][b].cost
+				if cur == inf {
+					continue
+				}
+				for nb := b; nb <= maxBits; nb++ {
+					np := p + 1<<nb
+					if np > symbols {
+						np = symbols
+					}
+					c := cur + (4+nb)*(sum[np]-sum[p])
+					if c < dp[g+1][np][nb].cost {
+						dp[g+1][np][nb] = state{cost: c, prev: p}
+					}
+				}
+			}
+		}
+	}
+	best := 0
+	for b := 1; b <= maxBits; b++ {
+		if dp[16][symbols][b].cost < dp[16][symbols][best].cost {
+			best = b
+		}
+	}
+	var out [16]int
+	p, b := symbols, best
+	for g := 16; g > 0; g-- {
+		out[g-1] = b
+		pp := dp[g][p][b].prev
+		if g > 1 {
+			// find bits of the previous group that led to this state
+			cost := dp[g][p][b].cost - (4+b)*(sum[p]-sum[pp])
+			for pb := 0; pb <= b; pb++ {
+				if dp[g-1][pp][pb].cost == cost {
+					b = pb
+					break
+				}
+			}
+		}
+		p = pp
+	}
+	return out
+}
+
+func (w *Writer) writeLiteral(c byte) {
+	if w.nsym >= rebuildCnt {
+		w.rebuild()
+	}
+	w.writeSym(int(c))
+}
+
+func (w *Writer) writeMatch(leng, dist int) {
+	if w.nsym >= rebuildCnt {
+		w.rebuild()
+	}
+	l := leng - minMatch
+	if l < 8 {
+		w.writeSym(0x100 + l)
+	} else {
+		for i := len(lenTable) - 1; i >= 0; i-- {
+			bits, offs := lenTable[i][0], lenTable[i][1]
+			if l >= offs {
+				w.writeSym(0x108 + i)
+				w.writeBits(uint64(l-offs), bits)
+				break
+			}
+		}
+	}
+	for code := len(distTable) - 1; code >= 0; code-- {
+		bits, offs := distTable[code][0], distTable[code][1]
+		if dist >= offs<<9 {
+			w.writeBits(uint64(code), 3)
+			w.writeBits(uint64(dist-offs<<9), bits+9)
+			break
+		}
+	}
+}
+
+func hash4(p []byte) int {
+	v := uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16 | uint32(p[3])<<24
+	return int((v * 2654435761) >> (32 - hashBits))
+}
+
+func (w *Writer) insert(pos int) {
+	i := pos - w.base
+	if i+minMatch > len(w.buf) {
+		return
+	}
+	h := hash4(w.buf[i:])
+	w.prev[pos%windowSize] = w.head[h]
+	w.head[h] = pos
+}
+
+// findMatch returns the longest match for the data at the current position.
+func (w *Writer) findMatch(end int) (leng, dist int) {
+	i := w.pos - w.base
+	if w.pos+minMatch > end {
+		return 0, 0
+	}
+	limit := end - w.pos
+	if limit > maxMatch {
+		limit = maxMatch
+	}
+	cur := w.buf[i : i+limit]
+	cand := w.head[hash4(cur)]
+	for n := 0; n < maxChain && cand >= 0 && w.pos-cand <= maxDist; n++ {
+		c := w.buf[cand-w.base:]
+		l := 0
+		for l < limit && c[l] == cur[l] {
+			l++
+		}
+		if l > leng {
+			leng, dist = l, w.pos-cand
+			if l == limit {
+				break
+			}
+		}
+		next := w.prev[cand%windowSize]
+		if next >= cand {
+			break // overwritten entry
+		}
+		cand = next
+	}
+	if leng < minMatch {
+		return 0, 0
+	}
+	return leng, dist
+}
+
+// encode pending data up to the absolute position end.
+func (w *Writer) encode(end int) {
+	for w.pos < end && w.err == nil {
+		leng, dist := w.findMatch(w.base + len(w.buf))
+		if leng > end-w.pos {
+			leng = end - w.pos
+		}
+		if leng >= minMatch {
+			w.writeMatch(leng, dist)
+		} else {
+			leng = 1
+			w.writeLiteral(w.buf[w.pos-w.base])
+		}
+		for i := 0; i < leng; i++ {
+			w.insert(w.pos)
+			w.pos++
+		}
+	}
+	// drop data that is out of the window
+	if n := w.pos - w.base - windowSize; n > 0 {
+		w.buf = append(w.buf[:0], w.buf[n:]...)
+		w.base += n
+	}
+}
+
+// Write compresses the data. Compressed data is written in blocks, and only flushed by Close.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.buf = append(w.buf, p...)
+	if end := w.base + len(w.buf); end-w.pos >= writeBlock {
+		// keep enough data for the longest match
+		w.encode(end - maxMatch)
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	return len(p), nil
+}
+
+// Close encodes the rest of the data and flushes it. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.encode(w.base + len(w.buf))
+	if w.err != nil {
+		return w.err
+	}
+	w.err = errors.New("nxz: writer is closed")
+	return w.bw.Close()
+}
@@ -0,0 +1,95 @@
+package nxz
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func testRoundTrip(t testing.TB, data []byte) []byte {
+	comp, err := Compress(data)
+	must.NoError(t, err)
+	got := make([]byte, len(data))
+	_, err = io.ReadFull(NewReader(bytes.NewReader(comp)), got)
+	must.NoError(t, err)
+	must.True(t, bytes.Equal(data, got))
+	return comp
+}
+
+func TestWriter(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	random := make([]byte, 100000)
+	rnd.Read(random)
+	// text-like data with a small alphabet and repetitions
+	words := strings.Fields("the quick brown fox jumps over a lazy dog and runs away from the castle")
+	var text bytes.Buffer
+	for text.Len() < 300000 {
+		text.WriteString(words[rnd.Intn(len(words))])
+		text.WriteByte(' ')
+	}
+	// data with matches at all distances, including ones close to the window size
+	far := make([]byte, 3*windowSize)
+	rnd.Read(far[:windowSize-10])
+	copy(far[windowSize-10:], far)
+
+	for _, c := range []struct {
+		name  string
+		data  []byte
+		ratio float64
+	}{
+		{"empty", nil, 0},
+		{"byte", []byte{42}, 0},
+		{"short", []byte("abcabcabcabc"), 0},
+		{"zeros", make([]byte, 200000), 0.01},
+		{"random", random, 1.2},
+		{"text", text.Bytes(), 0.5},
+		{"far", far, 0.4},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			comp := testRoundTrip(t, c.data)
+			if c.ratio != 0 {
+				ratio := float64(len(comp)) / float64(len(c.data))
+				must.Less(t, c.ratio, ratio)
+			}
+		})
+	}
+}
+
+func TestWriterChunks(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	data := make([]byte, 5*writeBlock+123)
+	for i := range data {
+		data[i] = byte(rnd.Intn(8))
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for p := data; len(p) > 0; {
+		n := rnd.Intn(10000)
+		if n > len(p) {
+			n = len(p)
+		}
+		_, err := w.Write(p[:n])
+		must.NoError(t, err)
+		p = p[n:]
+	}
+	must.NoError(t, w.Close())
+	got := make([]byte, len(data))
+	_, err := io.ReadFull(NewReader(&buf), got)
+	must.NoError(t, err)
+	must.True(t, bytes.Equal(data, got))
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.nxz")
+	data := bytes.Repeat([]byte("nox map data "), 1000)
+	err := WriteFile(path, data)
+	must.NoError(t, err)
+	got, err := ReadFile(path)
+	must.NoError(t, err)
+	must.Eq(t, data, got)
+}
@@ -0,0 +1,202 @@
+package strman
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// IssueKind is a kind of issue found by Check.
+type IssueKind string
+
+const (
+	// IssueMissing is reported for entries that are defined by the reference, but not by the translation.
+	IssueMissing = IssueKind("missing")
+	// IssueExtra is reported for entries that are defined by the translation, but not by the reference.
+	IssueExtra = IssueKind("extra")
+	// IssueArgs is reported when the number of format arguments differs.
+	IssueArgs = IssueKind("args")
+	// IssueVerbs is reported when format verbs differ in type or order.
+	IssueVerbs = IssueKind("verbs")
+	// IssueNewlines is reported when the number of line breaks differs.
+	IssueNewlines = IssueKind("newlines")
+	// IssueLongLine is reported for lines that exceed the length limit.
+	IssueLongLine = IssueKind("long_line")
+)
+
+// Issue is a single problem found by Check.
+type Issue struct {
+	ID      ID        `json:"id"`
+	Variant int       `json:"variant"`
+	Kind    IssueKind `json:"kind"`
+	Message string    `json:"message"`
+}
+
+func (e Issue) String() string {
+	return fmt.Sprintf("%s[%d]: %s: %s", e.ID, e.Variant, e.Kind, e.Message)
+}
+
+// CheckOptions controls translation checks.
+type CheckOptions struct {
+	// MaxLineLen is the maximal number of characters in a line of the translation. Zero means no limit.
+	MaxLineLen int
+	// MaxLineRatio limits line length, relative to the longest line of the reference string. Zero means no limit.
+	MaxLineRatio float64
+	// IgnoreMissing disables reporting of missing and extra entries, which is useful for partial translations.
+	IgnoreMissing bool
+}
+
+// Check compares a translation to a reference and reports inconsistencies:
+// mismatched printf-style format verbs and argument counts, line breaks, missing or extra entries and long lines.
+//
+// Each variant of the translation is compared to the variant of the reference with the same index,
+// or to the first reference variant if the reference has fewer variants.
+func Check(ref, tr *StringManager, opts *CheckOptions) []Issue {
+	var o CheckOptions
+	if opts != nil {
+		o = *opts
+	}
+	var out []Issue
+	for _, re := range ref.entries {
+		te, ok := tr.Get(re.ID)
+		if !ok {
+			if !o.IgnoreMissing {
+				out = append(out, Issue{ID: re.ID, Kind: IssueMissing, Message: "entry is not translated"})
+			}
+			continue
+		}
+		if len(re.Vals) == 0 {
+			continue
+		}
+		for i, tv := range te.Vals {
+			rv := re.Vals[0]
+			if i < len(re.Vals) {
+				rv = re.Vals[i]
+			}
+			out = checkVariant(out, re.ID, i, rv.Str, tv.Str, &o)
+		}
+	}
+	if !o.IgnoreMissing {
+		for _, te := range tr.entries {
+			if _, ok := ref.Get(te.ID); !ok {
+				out = append(out, Issue{ID: te.ID, Kind: IssueExtra, Message: "entry is not defined by the reference"})
+			}
+		}
+	}
+	return out
+}
+
+func checkVariant(out []Issue, id ID, vi int, ref, tr string, o *CheckOptions) []Issue {
+	add := func(kind IssueKind, format string, args ...any) {
+		out = append(out, Issue{ID: id, Variant: vi, Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+	rverbs, rargs := FormatVerbs(ref)
+	tverbs, targs := FormatVerbs(tr)
+	if rargs != targs {
+		add(IssueArgs, "expected %d format arguments, got %d", rargs, targs)
+	} else if !equalVerbs(rverbs, tverbs) {
+		add(IssueVerbs, "expected format verbs %q, got %q", rverbs, tverbs)
+	}
+	if rn, tn := strings.Count(ref, "\n"), strings.Count(tr, "\n"); rn != tn {
+		add(IssueNewlines, "expected %d line breaks, got %d", rn, tn)
+	}
+	limit := o.MaxLineLen
+	if o.MaxLineRatio > 0 {
+		// empty reference gives no relative limit
+		if rlimit := int(float64(longestLine(ref)) * o.MaxLineRatio); rlimit > 0 && (limit <= 0 || rlimit < limit) {
+			limit = rlimit
+		}
+	}
+	if limit > 0 {
+		for i, line := range strings.Split(tr, "\n") {
+			if n := utf8.RuneCountInString(line); n > limit {
+				add(IssueLongLine, "line %d is too long: %d characters, limit is %d", i+1, n, limit)
+			}
+		}
+	}
+	return out
+}
+
+func longestLine(s string) int {
+	n := 0
+	for _, line := range strings.Split(s, "\n") {
+		n = max(n, utf8.RuneCountInString(line))
+	}
+	return n
+}
+
+func equalVerbs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if verbClass(a[i]) != verbClass(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// verbClass returns a verb with flags, width and precision removed,
+// and with conversions that accept the same argument type merged.
+func verbClass(v string) string {
+	v = strings.TrimLeft(v[1:], "-+ #0123456789.*")
+	if v == "" {
+		return v
+	}
+	size, conv := v[:len(v)-1], v[len(v)-1]
+	switch conv {
+	case 'i', 'o', 'u', 'x', 'X':
+		conv = 'd'
+	case 'e', 'E', 'g', 'G', 'a', 'A', 'F':
+		conv = 'f'
+	}
+	return size + string(conv)
+}
+
+// FormatVerbs returns printf-style format verbs in the string and the number of arguments they consume.
+// Escaped percent signs ("%%") are skipped.
+func FormatVerbs(s string) ([]string, int) {
+	var (
+		verbs []string
+		args  int
+	)
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			continue
+		}
+		j := i + 1
+		if j < len(s) && s[j] == '%' {
+			i = j
+			continue
+		}
+		for j < len(s) && strings.IndexByte("-+ #0", s[j]) >= 0 {
+			j++ // flags
+		}
+		for j < len(s) && (s[j] == '*' || s[j] == '.' || (s[j] >= '0' && s[j] <= '9')) {
+			if s[j] == '*' {
+				args++ // width or precision argument
+			}
+			j++
+		}
+		for j < len(s) && strings.IndexByte("hlLqjztwI", s[j]) >= 0 {
+			// size modifiers, including MSVC-specific I32 and I64
+			if s[j] == 'I' && j+2 < len(s) && (s[j+1:j+3] == "32" || s[j+1:j+3] == "64") {
+				j += 2
+			}
+			j++
+		}
+		if j >= len(s) {
+			// incomplete verb at the end of the string
+			verbs = append(verbs, s[i:])
+			args++
+			break
+		}
+		_, sz := utf8.DecodeRuneInString(s[j:])
+		j += sz
+		verbs = append(verbs, s[i:j])
+		args++
+		i = j - 1
+	}
+	return verbs, args
+}
@@ -0,0 +1,73 @@
+package strman
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestFormatVerbs(t *testing.T) {
+	for _, c := range []struct {
+		s     string
+		verbs []string
+		args  int
+	}{
+		{"no verbs", nil, 0},
+		{"100%% sure", nil, 0},
+		{"%s has %d gold", []string{"%s", "%d"}, 2},
+		{"%-5.2f%ls%I64u", []string{"%-5.2f", "%ls", "%I64u"}, 3},
+		{"%*d", []string{"%*d"}, 2},
+		{"broken %", []string{"%"}, 1},
+	} {
+		verbs, args := FormatVerbs(c.s)
+		must.Eq(t, c.verbs, verbs, must.Sprint(c.s))
+		must.EqOp(t, c.args, args, must.Sprint(c.s))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ref := newTestManager(t,
+		Entry{ID: "A:Ok", Vals: []Variant{{Str: "%s has %d gold"}}},
+		Entry{ID: "A:Order", Vals: []Variant{{Str: "%s has %d gold"}}},
+		Entry{ID: "A:Args", Vals: []Variant{{Str: "%s has %d gold"}}},
+		Entry{ID: "A:Lines", Vals: []Variant{{Str: "one\ntwo"}, {Str: "%d"}}},
+		Entry{ID: "A:Empty", Vals: []Variant{{Str: ""}}},
+		Entry{ID: "A:Missing", Vals: []Variant{{Str: "x"}}},
+	)
+	tr := newTestManager(t,
+		Entry{ID: "a:ok", Vals: []Variant{{Str: "%s hat %i Gold"}}},
+		Entry{ID: "A:Order", Vals: []Variant{{Str: "%d Gold hat %s"}}},
+		Entry{ID: "A:Args", Vals: []Variant{{Str: "%s hat Gold"}}},
+		Entry{ID: "A:Lines", Vals: []Variant{{Str: "eins zwei drei"}, {Str: "%d"}, {Str: "%s"}}},
+		Entry{ID: "A:Empty", Vals: []Variant{{Str: "nicht mehr leer"}}},
+		Entry{ID: "A:Extra", Vals: []Variant{{Str: "x"}}},
+	)
+	issues := Check(ref, tr, nil)
+	must.Eq(t, []Issue{
+		{ID: "A:Order", Kind: IssueVerbs, Message: `expected format verbs ["%s" "%d"], got ["%d" "%s"]`},
+		{ID: "A:Args", Kind: IssueArgs, Message: "expected 2 format arguments, got 1"},
+		{ID: "A:Lines", Kind: IssueNewlines, Message: "expected 1 line breaks, got 0"},
+		// compared to the first variant
+		{ID: "A:Lines", Variant: 2, Kind: IssueArgs, Message: "expected 0 format arguments, got 1"},
+		{ID: "A:Lines", Variant: 2, Kind: IssueNewlines, Message: "expected 1 line breaks, got 0"},
+		{ID: "A:Missing", Kind: IssueMissing, Message: "entry is not translated"},
+		{ID: "A:Extra", Kind: IssueExtra, Message: "entry is not defined by the reference"},
+	}, issues)
+
+	issues = Check(ref, tr, &CheckOptions{MaxLineLen: 12, MaxLineRatio: 2, IgnoreMissing: true})
+	var long []Issue
+	for _, e := range issues {
+		must.NotEq(t, IssueMissing, e.Kind)
+		must.NotEq(t, IssueExtra, e.Kind)
+		if e.Kind == IssueLongLine {
+			long = append(long, e)
+		}
+	}
+	must.Eq(t, []Issue{
+		{ID: "A:Ok", Kind: IssueLongLine, Message: "line 1 is too long: 14 characters, limit is 12"},
+		{ID: "A:Order", Kind: IssueLongLine, Message: "line 1 is too long: 14 characters, limit is 12"},
+		{ID: "A:Lines", Kind: IssueLongLine, Message: "line 1 is too long: 14 characters, limit is 6"},
+		// empty reference must not disable the absolute limit
+		{ID: "A:Empty", Kind: IssueLongLine, Message: "line 1 is too long: 15 characters, limit is 12"},
+	}, long)
+}
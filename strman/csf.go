@@ -1,6 +1,7 @@
 package strman
 
 import (
+	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 )
 
 func (sm *StringManager) ReadCSF(path string) error {
+	sm.cp1251 = false
 	if err := sm.readCSF(path, nil); err != nil {
 		return err
 	}
@@ -36,6 +38,7 @@ func (sm *StringManager) ReadCSF(path string) error {
 		}); err != nil {
 			return err
 		}
+		sm.cp1251 = true
 	}
 	return nil
 }
@@ -57,6 +60,7 @@ func (sm *StringManager) readCSF(path string, conv func(data []uint16) string) e
 	vers := binary.LittleEndian.Uint32(buf[4:8])
 	cntEnt := binary.LittleEndian.Uint32(buf[8:12])
 	cntVar := binary.LittleEndian.Uint32(buf[12:16])
+	sm.unk16 = binary.LittleEndian.Uint32(buf[16:20])
 	if vers < 2 {
 		sm.lang = 0
 		_, err = f.Seek(20, io.SeekStart)
@@ -133,3 +137,86 @@ func (sm *StringManager) readCSF(path string, conv func(data []uint16) string) e
 	}
 	return sm.indexEntries()
 }
+
+// WriteCSF writes strings in CSF format.
+//
+// If strings were read from a CSF file that uses CP-1251 instead of UTF-16 (directly or through JSON),
+// the same encoding is used for writing.
+func (sm *StringManager) WriteCSF(path string) error {
+	f, err := ifs.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = sm.EncodeCSF(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// EncodeCSF writes strings in CSF format.
+func (sm *StringManager) EncodeCSF(w io.Writer) error {
+	var enc func(s string) ([]uint16, error)
+	if sm.cp1251 {
+		cp := charmap.Windows1251.NewEncoder()
+		enc = func(s string) ([]uint16, error) {
+			data, err := cp.String(s)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]uint16, len(data))
+			for i := range data {
+				out[i] = uint16(data[i])
+			}
+			return out, nil
+		}
+	} else {
+		enc = func(s string) ([]uint16, error) {
+			return utf16.Encode([]rune(s)), nil
+		}
+	}
+	bw := bufio.NewWriter(w)
+	var buf [24]byte
+	cntVar := 0
+	for _, e := range sm.entries {
+		cntVar += len(e.Vals)
+	}
+	copy(buf[0:4], reverse4s([]byte("CSF ")))
+	binary.LittleEndian.PutUint32(buf[4:8], 3) // version
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(len(sm.entries)))
+	binary.LittleEndian.PutUint32(buf[12:16], uint32(cntVar))
+	binary.LittleEndian.PutUint32(buf[16:20], sm.unk16)
+	binary.LittleEndian.PutUint32(buf[20:24], uint32(sm.lang))
+	bw.Write(buf[:24])
+	for _, e := range sm.entries {
+		copy(buf[0:4], reverse4s([]byte("LBL ")))
+		binary.LittleEndian.PutUint32(buf[4:8], uint32(len(e.Vals)))
+		binary.LittleEndian.PutUint32(buf[8:12], uint32(len(e.ID)))
+		bw.Write(buf[:12])
+		bw.WriteString(string(e.ID))
+		for _, v := range e.Vals {
+			str, err := enc(v.Str)
+			if err != nil {
+				return fmt.Errorf("cannot encode %q: %w", e.ID, err)
+			}
+			invertUTF16(str)
+			sect := "STR "
+			if v.Str2 != "" {
+				sect = "STRW"
+			}
+			copy(buf[0:4], reverse4s([]byte(sect)))
+			binary.LittleEndian.PutUint32(buf[4:8], uint32(len(str)))
+			bw.Write(buf[:8])
+			for _, c := range str {
+				binary.LittleEndian.PutUint16(buf[:2], c)
+				bw.Write(buf[:2])
+			}
+			if v.Str2 != "" {
+				binary.LittleEndian.PutUint32(buf[:4], uint32(len(v.Str2)))
+				bw.Write(buf[:4])
+				bw.WriteString(v.Str2)
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,74 @@
+package strman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func testEntries() []Entry {
+	return []Entry{
+		{ID: "GUI:Ok", Vals: []Variant{{Str: "OK"}}},
+		{ID: "Con:Greet", Vals: []Variant{
+			{Str: "Hello, \"stranger\"!\nWelcome."},
+			{Str: "Привет", Str2: "Greet02"},
+		}},
+		{ID: "Empty:Str", Vals: []Variant{{Str: ""}}},
+	}
+}
+
+func TestCSFRoundTrip(t *testing.T) {
+	sm := New()
+	sm.lang = 2
+	sm.entries = testEntries()
+	must.NoError(t, sm.indexEntries())
+
+	path := filepath.Join(t.TempDir(), "test.csf")
+	err := sm.WriteCSF(path)
+	must.NoError(t, err)
+
+	sm2 := New()
+	err = sm2.ReadCSF(path)
+	must.NoError(t, err)
+	must.EqOp(t, 2, sm2.Lang())
+	must.Eq(t, sm.entries, sm2.entries)
+	must.EqOp(t, "OK", sm2.GetString("gui:ok"))
+}
+
+func TestCSFRoundTripCP1251(t *testing.T) {
+	sm := New()
+	sm.cp1251 = true
+	sm.unk16 = 0x1234
+	sm.entries = []Entry{
+		{ID: "QuitMenu.wnd:Quit", Vals: []Variant{{Str: "Выход"}}},
+		{ID: "GUI:Ok", Vals: []Variant{{Str: "Да"}}},
+	}
+	path := filepath.Join(t.TempDir(), "test.csf")
+	err := sm.WriteCSF(path)
+	must.NoError(t, err)
+
+	sm2 := New()
+	err = sm2.ReadCSF(path)
+	must.NoError(t, err)
+	must.True(t, sm2.cp1251)
+	must.EqOp(t, sm.unk16, sm2.unk16)
+	must.Eq(t, sm.entries, sm2.entries)
+
+	// conversion to JSON and back must produce the same file
+	jpath := filepath.Join(t.TempDir(), "test.json")
+	err = sm2.WriteJSON(jpath, true)
+	must.NoError(t, err)
+	sm3 := New()
+	err = sm3.ReadJSON(jpath)
+	must.NoError(t, err)
+	path2 := filepath.Join(t.TempDir(), "test2.csf")
+	err = sm3.WriteCSF(path2)
+	must.NoError(t, err)
+	exp, err := os.ReadFile(path)
+	must.NoError(t, err)
+	got, err := os.ReadFile(path2)
+	must.NoError(t, err)
+	must.Eq(t, exp, got)
+}
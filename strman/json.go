@@ -6,12 +6,18 @@ import (
 )
 
 type jsonFile struct {
-	Lang    int     `json:"lang,omitempty"`
+	Lang int `json:"lang,omitempty"`
+	// CP1251 is set for CSF files that use CP-1251 instead of UTF-16. See WriteCSF.
+	CP1251 bool `json:"cp1251,omitempty"`
+	// Unk16 is an unknown CSF header field.
+	Unk16   uint32  `json:"csf_unk16,omitempty"`
 	Entries []Entry `json:"entries"`
 }
 
 func (sm *StringManager) ReadJSON(path string) error {
 	sm.lang = 0
+	sm.cp1251 = false
+	sm.unk16 = 0
 	sm.entries = nil
 	var file jsonFile
 	f, err := os.Open(path)
@@ -23,6 +29,8 @@ func (sm *StringManager) ReadJSON(path string) error {
 		return err
 	}
 	sm.lang = file.Lang
+	sm.cp1251 = file.CP1251
+	sm.unk16 = file.Unk16
 	// TODO: pack values in memory?
 	sm.entries = file.Entries
 	return sm.indexEntries()
@@ -40,6 +48,8 @@ func (sm *StringManager) WriteJSON(path string, pretty bool) error {
 	}
 	if err = enc.Encode(jsonFile{
 		Lang:    sm.lang,
+		CP1251:  sm.cp1251,
+		Unk16:   sm.unk16,
 		Entries: sm.entries,
 	}); err != nil {
 		return err
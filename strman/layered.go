@@ -0,0 +1,118 @@
+package strman
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layer is a named string source for Layered.
+type Layer struct {
+	Name string
+	*StringManager
+}
+
+// NewLayered creates a string manager that resolves strings through a chain of layers.
+// Layers are given in priority order, for example: mod overrides, user's language, English.
+// Layer names must be unique.
+func NewLayered(layers ...Layer) *Layered {
+	return &Layered{layers: layers}
+}
+
+// Layered stacks several string managers. Each lookup returns an entry from the first layer that defines it.
+//
+// This allows mods to override only a few strings, and allows partial translations to fall back to English.
+type Layered struct {
+	layers []Layer
+}
+
+// Layers returns all layers in priority order.
+func (l *Layered) Layers() []Layer {
+	return l.layers
+}
+
+// Layer returns a string manager for a given layer name, or nil if there is no such layer.
+func (l *Layered) Layer(name string) *StringManager {
+	for _, ly := range l.layers {
+		if ly.Name == name {
+			return ly.StringManager
+		}
+	}
+	return nil
+}
+
+// AddLayer adds a layer with the lowest priority.
+func (l *Layered) AddLayer(name string, sm *StringManager) {
+	l.layers = append(l.layers, Layer{Name: name, StringManager: sm})
+}
+
+// Lookup finds an entry and returns the name of the layer that defines it.
+// Entries without any values are skipped, so that lookup falls through to the next layer.
+func (l *Layered) Lookup(id ID) (Entry, string, bool) {
+	for _, ly := range l.layers {
+		if e, ok := ly.Get(id); ok && len(e.Vals) != 0 {
+			return e, ly.Name, true
+		}
+	}
+	return Entry{}, "", false
+}
+
+func (l *Layered) Get(id ID) (Entry, bool) {
+	e, _, ok := l.Lookup(id)
+	return e, ok
+}
+
+func (l *Layered) GetInFile(id ID, file string) (Entry, bool) {
+	return l.Get(fileID(file, id))
+}
+
+func (l *Layered) GetVariant(id ID) (Variant, bool) {
+	e, ok := l.Get(id)
+	if !ok || len(e.Vals) == 0 {
+		return Variant{Str: fmt.Sprintf("MISSING:'%s'", id)}, false
+	}
+	return e.Value(), true
+}
+
+func (l *Layered) GetString(id ID) string {
+	s, _ := l.GetVariant(id)
+	return s.Str
+}
+
+func (l *Layered) GetVariantInFile(id ID, file string) (Variant, bool) {
+	return l.GetVariant(fileID(file, id))
+}
+
+func (l *Layered) GetStringInFile(id ID, file string) string {
+	s, _ := l.GetVariant(fileID(file, id))
+	return s.Str
+}
+
+// Missing returns IDs that are defined by any other layer, but not by the given one.
+// Entries without any values are considered missing, same as in Lookup.
+// IDs are returned in the order of layers, and then in the order of entries in each layer.
+func (l *Layered) Missing(name string) []ID {
+	sm := l.Layer(name)
+	if sm == nil {
+		return nil
+	}
+	var (
+		out  []ID
+		seen = make(map[ID]struct{})
+	)
+	for _, ly := range l.layers {
+		if ly.StringManager == sm {
+			continue
+		}
+		for _, e := range ly.entries {
+			key := ID(strings.ToLower(string(e.ID)))
+			if _, ok := seen[key]; ok || len(e.Vals) == 0 {
+				continue
+			}
+			seen[key] = struct{}{}
+			if own, ok := sm.Get(e.ID); !ok || len(own.Vals) == 0 {
+				out = append(out, e.ID)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package strman
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func newTestManager(t testing.TB, entries ...Entry) *StringManager {
+	sm := New()
+	sm.entries = entries
+	must.NoError(t, sm.indexEntries())
+	return sm
+}
+
+func TestLayered(t *testing.T) {
+	mod := newTestManager(t,
+		Entry{ID: "GUI:Ok", Vals: []Variant{{Str: "Sure"}}},
+		Entry{ID: "Mod:New", Vals: []Variant{{Str: "Mod string"}}},
+	)
+	de := newTestManager(t,
+		Entry{ID: "GUI:Ok", Vals: []Variant{{Str: "OK"}}},
+		Entry{ID: "GUI:Cancel", Vals: []Variant{{Str: "Abbrechen"}}},
+		Entry{ID: "GUI:Help"}, // no values
+	)
+	en := newTestManager(t,
+		Entry{ID: "GUI:Ok", Vals: []Variant{{Str: "OK"}}},
+		Entry{ID: "GUI:Cancel", Vals: []Variant{{Str: "Cancel"}}},
+		Entry{ID: "GUI:Help", Vals: []Variant{{Str: "Help"}}},
+	)
+	l := NewLayered(Layer{Name: "mod", StringManager: mod}, Layer{Name: "de", StringManager: de})
+	l.AddLayer("en", en)
+	must.Len(t, 3, l.Layers())
+	must.EqOp(t, en, l.Layer("en"))
+	must.Nil(t, l.Layer("fr"))
+
+	for _, c := range []struct {
+		id    ID
+		str   string
+		layer string
+	}{
+		{"gui:ok", "Sure", "mod"},
+		{"GUI:Cancel", "Abbrechen", "de"},
+		{"GUI:Help", "Help", "en"},
+		{"Mod:New", "Mod string", "mod"},
+	} {
+		e, layer, ok := l.Lookup(c.id)
+		must.True(t, ok)
+		must.EqOp(t, c.layer, layer)
+		must.EqOp(t, c.str, e.Vals[0].Str)
+		must.EqOp(t, c.str, l.GetString(c.id))
+	}
+	must.EqOp(t, "Help", l.GetStringInFile("Help", "GUI"))
+	_, ok := l.GetInFile("Help", "Other")
+	must.False(t, ok)
+
+	_, layer, ok := l.Lookup("GUI:Missing")
+	must.False(t, ok)
+	must.EqOp(t, "", layer)
+	must.EqOp(t, "MISSING:'GUI:Missing'", l.GetString("GUI:Missing"))
+
+	must.Eq(t, []ID{"GUI:Cancel", "GUI:Help"}, l.Missing("mod"))
+	must.Eq(t, []ID{"Mod:New", "GUI:Help"}, l.Missing("de"))
+	must.Eq(t, []ID{"Mod:New"}, l.Missing("en"))
+	must.Nil(t, l.Missing("fr"))
+}
@@ -0,0 +1,311 @@
+package strman
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/noxworld-dev/opennox-lib/ifs"
+)
+
+const (
+	poWavePrefix = "wave: "
+	poLangHeader = "X-Nox-Lang"
+)
+
+// poContext returns msgctxt for the variant of the entry.
+// Entries with a single variant use ID directly, otherwise a 1-based variant index is appended: "ID#2".
+func poContext(id ID, i, n int) string {
+	if n == 1 {
+		return string(id)
+	}
+	return string(id) + "#" + strconv.Itoa(i+1)
+}
+
+// parsePOContext is the reverse of poContext. It returns -1 as index for entries with a single variant.
+func parsePOContext(ctx string) (ID, int) {
+	if i := strings.LastIndexByte(ctx, '#'); i >= 0 {
+		if n, err := strconv.Atoi(ctx[i+1:]); err == nil && n > 0 {
+			return ID(ctx[:i]), n - 1
+		}
+	}
+	return ID(ctx), -1
+}
+
+// WritePO writes strings as gettext PO file, using this string manager as a source language.
+// If translation is nil, a template (POT) is written instead. See EncodePO.
+func (sm *StringManager) WritePO(path string, tr *StringManager) error {
+	f, err := ifs.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = sm.EncodePO(f, tr); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// EncodePO writes strings as gettext PO file, using this string manager as a source language.
+// If translation is nil, a template (POT) is written instead.
+//
+// Each string variant becomes a separate message with ID as msgctxt (see poContext) and the source string as msgid.
+// Variant comments are written as translator comments, and wave names as extracted comments.
+func (sm *StringManager) EncodePO(w io.Writer, tr *StringManager) error {
+	bw := bufio.NewWriter(w)
+	hdr := "Content-Type: text/plain; charset=UTF-8\n"
+	if tr != nil {
+		hdr += poLangHeader + ": " + strconv.Itoa(tr.lang) + "\n"
+	}
+	writePOString(bw, "msgid", "")
+	writePOString(bw, "msgstr", hdr)
+	for _, e := range sm.entries {
+		var trVals []Variant
+		if tr != nil {
+			if te, ok := tr.Get(e.ID); ok {
+				trVals = te.Vals
+			}
+		}
+		for i, v := range e.Vals {
+			var tv Variant
+			if i < len(trVals) {
+				tv = trVals[i]
+			}
+			bw.WriteString("\n")
+			comment := tv.Comment
+			if comment == "" {
+				comment = v.Comment
+			}
+			for _, line := range strings.Split(comment, "\n") {
+				if line != "" {
+					bw.WriteString("# " + line + "\n")
+				}
+			}
+			wave := tv.Str2
+			if wave == "" {
+				wave = v.Str2
+			}
+			if wave != "" {
+				bw.WriteString("#. " + poWavePrefix + wave + "\n")
+			}
+			writePOString(bw, "msgctxt", poContext(e.ID, i, len(e.Vals)))
+			writePOString(bw, "msgid", v.Str)
+			writePOString(bw, "msgstr", tv.Str)
+		}
+	}
+	return bw.Flush()
+}
+
+var poEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+func writePOString(w *bufio.Writer, key, s string) {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		fmt.Fprintf(w, "%s \"%s\"\n", key, poEscaper.Replace(s))
+		return
+	}
+	fmt.Fprintf(w, "%s \"\"\n", key)
+	for _, line := range lines {
+		fmt.Fprintf(w, "\"%s\"\n", poEscaper.Replace(line))
+	}
+}
+
+type poMessage struct {
+	comments  []string
+	extracted []string
+	fuzzy     bool
+	ctx       string
+	id        string
+	str       string
+}
+
+// ReadPO reads strings from gettext PO file. See DecodePO.
+func (sm *StringManager) ReadPO(path string, src *StringManager) error {
+	f, err := ifs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return sm.DecodePO(f, src)
+}
+
+// DecodePO reads strings from gettext PO file, as written by EncodePO.
+//
+// Untranslated and fuzzy messages fall back to the source string (msgid).
+// If source string manager is set, entries are ordered the same way as in the source,
+// and entries missing from the PO file are copied from it.
+//
+// PO files are always in UTF-8, thus CP-1251 encoding for CSF must be enabled explicitly, see SetCP1251.
+func (sm *StringManager) DecodePO(r io.Reader, src *StringManager) error {
+	msgs, err := parsePO(r)
+	if err != nil {
+		return err
+	}
+	sm.lang = 0
+	sm.cp1251 = false
+	sm.unk16 = 0
+	sm.entries = nil
+	if src != nil {
+		sm.lang = src.lang
+	}
+	byID := make(map[ID]int)
+	addEntry := func(id ID) *Entry {
+		key := ID(strings.ToLower(string(id)))
+		if i, ok := byID[key]; ok {
+			return &sm.entries[i]
+		}
+		byID[key] = len(sm.entries)
+		sm.entries = append(sm.entries, Entry{ID: id})
+		return &sm.entries[len(sm.entries)-1]
+	}
+	if src != nil {
+		for _, e := range src.entries {
+			ent := addEntry(e.ID)
+			ent.Vals = slices.Clone(e.Vals)
+		}
+	}
+	for _, m := range msgs {
+		if m.ctx == "" && m.id == "" {
+			// header
+			for _, line := range strings.Split(m.str, "\n") {
+				if v, ok := strings.CutPrefix(line, poLangHeader+":"); ok {
+					if sm.lang, err = strconv.Atoi(strings.TrimSpace(v)); err != nil {
+						return fmt.Errorf("invalid %s header: %w", poLangHeader, err)
+					}
+				}
+			}
+			continue
+		}
+		if m.ctx == "" {
+			return fmt.Errorf("message %q has no context", m.id)
+		}
+		id, ind := parsePOContext(m.ctx)
+		v := Variant{Str: m.str, Comment: strings.Join(m.comments, "\n")}
+		if v.Str == "" || m.fuzzy {
+			v.Str = m.id
+		}
+		for _, c := range m.extracted {
+			if wave, ok := strings.CutPrefix(c, poWavePrefix); ok {
+				v.Str2 = wave
+			}
+		}
+		ent := addEntry(id)
+		if ind < 0 {
+			ind = 0
+		}
+		for len(ent.Vals) <= ind {
+			ent.Vals = append(ent.Vals, Variant{})
+		}
+		ent.Vals[ind] = v
+	}
+	return sm.indexEntries()
+}
+
+func parsePO(r io.Reader) ([]poMessage, error) {
+	var (
+		out     []poMessage
+		cur     poMessage
+		has     bool    // cur has any keywords
+		dst     *string // destination for continuation lines
+		discard string
+		line    int
+	)
+	flush := func() {
+		if has {
+			out = append(out, cur)
+		}
+		cur, has, dst = poMessage{}, false, nil
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if line == 1 {
+			s = strings.TrimPrefix(s, "\ufeff") // BOM
+		}
+		switch {
+		case s == "":
+			flush()
+			continue
+		case strings.HasPrefix(s, "#"):
+			if has {
+				flush()
+			}
+			switch {
+			case strings.HasPrefix(s, "#."):
+				cur.extracted = append(cur.extracted, strings.TrimSpace(s[2:]))
+			case strings.HasPrefix(s, "#,"):
+				for _, fl := range strings.Split(s[2:], ",") {
+					if strings.TrimSpace(fl) == "fuzzy" {
+						cur.fuzzy = true
+					}
+				}
+			case strings.HasPrefix(s, "# "), s == "#":
+				cur.comments = append(cur.comments, strings.TrimPrefix(s[1:], " "))
+			}
+			// references, previous strings and obsolete messages are ignored
+			continue
+		case s[0] == '"':
+			if dst == nil {
+				return nil, fmt.Errorf("line %d: unexpected string", line)
+			}
+			str, err := unquotePO(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			*dst += str
+			continue
+		}
+		key, val, _ := strings.Cut(s, " ")
+		switch key {
+		case "msgctxt":
+			if has {
+				flush()
+			}
+			dst = &cur.ctx
+		case "msgid":
+			if has && (cur.id != "" || cur.str != "") {
+				flush()
+			}
+			dst = &cur.id
+		case "msgstr", "msgstr[0]":
+			dst = &cur.str
+		default:
+			if key != "msgid_plural" && !strings.HasPrefix(key, "msgstr[") {
+				return nil, fmt.Errorf("line %d: unexpected keyword: %q", line, key)
+			}
+			dst = &discard // only the singular form is used
+		}
+		has = true
+		str, err := unquotePO(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		*dst = str
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return out, nil
+}
+
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected a quoted string: %q", s)
+	}
+	str, rest, err := unquoteSTR(s)
+	if err != nil {
+		return "", err
+	} else if rest != "" {
+		return "", fmt.Errorf("unexpected text after the string: %q", s)
+	}
+	return str, nil
+}
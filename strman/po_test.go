@@ -0,0 +1,99 @@
+package strman
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestPORoundTrip(t *testing.T) {
+	src := New()
+	src.entries = testEntries()
+	src.entries[0].Vals[0].Comment = "button"
+	must.NoError(t, src.indexEntries())
+
+	tr := New()
+	tr.lang = 5
+	tr.entries = []Entry{
+		{ID: "gui:ok", Vals: []Variant{{Str: "Gut"}}},
+		{ID: "Con:Greet", Vals: []Variant{
+			{Str: "Hallo, \"Fremder\"!\nWillkommen.", Comment: "first line\nsecond line"},
+		}},
+	}
+	must.NoError(t, tr.indexEntries())
+
+	var buf bytes.Buffer
+	err := src.EncodePO(&buf, tr)
+	must.NoError(t, err)
+	po := buf.String()
+	must.StrContains(t, po, "# button\nmsgctxt \"GUI:Ok\"\nmsgid \"OK\"\nmsgstr \"Gut\"\n")
+	must.StrContains(t, po, "#. wave: Greet02\nmsgctxt \"Con:Greet#2\"\n")
+	must.StrContains(t, po, "msgstr \"\"\n\"Hallo, \\\"Fremder\\\"!\\n\"\n\"Willkommen.\"\n")
+
+	got := New()
+	err = got.DecodePO(strings.NewReader(po), nil)
+	must.NoError(t, err)
+	must.EqOp(t, 5, got.Lang())
+	must.Eq(t, []Entry{
+		{ID: "GUI:Ok", Vals: []Variant{{Str: "Gut", Comment: "button"}}},
+		{ID: "Con:Greet", Vals: []Variant{
+			{Str: "Hallo, \"Fremder\"!\nWillkommen.", Comment: "first line\nsecond line"},
+			// missing translation
+			{Str: "Привет", Str2: "Greet02"},
+		}},
+		{ID: "Empty:Str", Vals: []Variant{{Str: ""}}},
+	}, got.entries)
+	must.EqOp(t, "Gut", got.GetString("gui:ok"))
+
+	// template
+	buf.Reset()
+	err = src.EncodePO(&buf, nil)
+	must.NoError(t, err)
+	must.StrNotContains(t, buf.String(), poLangHeader)
+	must.StrContains(t, buf.String(), "msgctxt \"GUI:Ok\"\nmsgid \"OK\"\nmsgstr \"\"\n")
+}
+
+func TestDecodePOFallback(t *testing.T) {
+	src := New()
+	src.lang = 1
+	src.cp1251 = true
+	src.entries = testEntries()
+	must.NoError(t, src.indexEntries())
+
+	const po = `# header comment
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#, fuzzy
+msgctxt "GUI:Ok"
+msgid "OK"
+msgstr "Maybe"
+
+msgctxt "New:Str"
+msgid "one"
+msgid_plural "many"
+msgstr[0] "eins"
+msgstr[1] "viele"
+"!"
+`
+	sm := New()
+	err := sm.DecodePO(strings.NewReader(po), src)
+	must.NoError(t, err)
+	must.EqOp(t, 1, sm.Lang())
+	// encoding of the source does not affect the translation
+	must.False(t, sm.cp1251)
+	must.Len(t, 4, sm.entries)
+	// fuzzy translation is not used
+	must.EqOp(t, "OK", sm.GetString("GUI:Ok"))
+	// entries missing from the PO file are copied from the source
+	must.Eq(t, src.entries[1], sm.entries[1])
+	must.EqOp(t, "eins", sm.GetString("New:Str"))
+
+	err = New().DecodePO(strings.NewReader("msgid \"x\"\nmsgstr \"y\"\n"), nil)
+	must.Error(t, err)
+	err = New().DecodePO(strings.NewReader("msgctxt \"x\"\nmsgid x\n"), nil)
+	must.Error(t, err)
+}
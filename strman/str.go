@@ -0,0 +1,145 @@
+package strman
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/noxworld-dev/opennox-lib/ifs"
+)
+
+// ReadSTR reads strings in a legacy text format (*.str):
+//
+//	// comment
+//	Label:Name
+//	"first variant"
+//	"second variant with a wave file"=Wave
+//	END
+//
+// Strings support \n, \t, \" and \\ escape sequences.
+func (sm *StringManager) ReadSTR(path string) error {
+	f, err := ifs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return sm.DecodeSTR(f)
+}
+
+// DecodeSTR reads strings in a legacy text format. See ReadSTR.
+func (sm *StringManager) DecodeSTR(r io.Reader) error {
+	sm.lang = 0
+	sm.cp1251 = false
+	sm.unk16 = 0
+	sm.entries = nil
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	var (
+		cur  *Entry
+		line int
+	)
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if line == 1 {
+			s = strings.TrimPrefix(s, "\ufeff") // BOM
+		}
+		if s == "" || strings.HasPrefix(s, "//") {
+			continue
+		}
+		if cur == nil {
+			sm.entries = append(sm.entries, Entry{ID: ID(s)})
+			cur = &sm.entries[len(sm.entries)-1]
+			continue
+		}
+		if strings.EqualFold(s, "END") {
+			cur = nil
+			continue
+		}
+		if s[0] != '"' {
+			return fmt.Errorf("line %d: expected a quoted string or END, got: %q", line, s)
+		}
+		str, rest, err := unquoteSTR(s)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		v := Variant{Str: str}
+		if rest = strings.TrimSpace(rest); rest != "" {
+			wave, ok := strings.CutPrefix(rest, "=")
+			if !ok {
+				return fmt.Errorf("line %d: unexpected text after the string: %q", line, rest)
+			}
+			v.Str2 = strings.TrimSpace(wave)
+		}
+		cur.Vals = append(cur.Vals, v)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if cur != nil {
+		return fmt.Errorf("missing END for %q", cur.ID)
+	}
+	return sm.indexEntries()
+}
+
+// unquoteSTR parses a quoted string at the beginning of s and returns the rest of the line.
+func unquoteSTR(s string) (string, string, error) {
+	var buf strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			return buf.String(), s[i+1:], nil
+		case '\\':
+			i++
+			if i >= len(s) {
+				return "", "", fmt.Errorf("unterminated string: %q", s)
+			}
+			switch c = s[i]; c {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return "", "", fmt.Errorf("unterminated string: %q", s)
+}
+
+var strEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+// WriteSTR writes strings in a legacy text format. See ReadSTR.
+func (sm *StringManager) WriteSTR(path string) error {
+	f, err := ifs.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = sm.EncodeSTR(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// EncodeSTR writes strings in a legacy text format. See ReadSTR.
+func (sm *StringManager) EncodeSTR(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range sm.entries {
+		bw.WriteString(string(e.ID))
+		bw.WriteString("\n")
+		for _, v := range e.Vals {
+			bw.WriteString(`"`)
+			strEscaper.WriteString(bw, v.Str)
+			bw.WriteString(`"`)
+			if v.Str2 != "" {
+				bw.WriteString("=")
+				bw.WriteString(v.Str2)
+			}
+			bw.WriteString("\n")
+		}
+		bw.WriteString("END\n\n")
+	}
+	return bw.Flush()
+}
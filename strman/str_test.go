@@ -0,0 +1,44 @@
+package strman
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestSTRRoundTrip(t *testing.T) {
+	sm := New()
+	sm.entries = testEntries()
+	var buf bytes.Buffer
+	err := sm.EncodeSTR(&buf)
+	must.NoError(t, err)
+	must.StrContains(t, buf.String(), `"Hello, \"stranger\"!\nWelcome."`)
+	must.StrContains(t, buf.String(), `"Привет"=Greet02`)
+
+	sm2 := New()
+	err = sm2.DecodeSTR(&buf)
+	must.NoError(t, err)
+	must.Eq(t, sm.entries, sm2.entries)
+}
+
+func TestDecodeSTR(t *testing.T) {
+	const text = "\ufeff// comment\r\n\r\nGUI:Ok\r\n  \"OK\"  \r\nend\r\nGUI:Two\n\"a\\tb\"\n\"c\" = Wave\nEND\n"
+	sm := New()
+	err := sm.DecodeSTR(bytes.NewBufferString(text))
+	must.NoError(t, err)
+	must.Eq(t, []Entry{
+		{ID: "GUI:Ok", Vals: []Variant{{Str: "OK"}}},
+		{ID: "GUI:Two", Vals: []Variant{{Str: "a\tb"}, {Str: "c", Str2: "Wave"}}},
+	}, sm.entries)
+
+	for _, bad := range []string{
+		"GUI:Ok\n\"OK\"\n",
+		"GUI:Ok\nOK\nEND\n",
+		"GUI:Ok\n\"OK\nEND\n",
+		"GUI:Ok\n\"OK\" x\nEND\n",
+	} {
+		err = New().DecodeSTR(bytes.NewBufferString(bad))
+		must.Error(t, err, must.Sprintf("%q", bad))
+	}
+}
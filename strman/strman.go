@@ -30,6 +30,8 @@ func (e Entry) Value() Variant {
 type Variant struct {
 	Str  string `json:"str"`
 	Str2 string `json:"str2,omitempty"`
+	// Comment for translators. It is not stored in CSF files.
+	Comment string `json:"comment,omitempty"`
 }
 
 func New() *StringManager {
@@ -38,6 +40,8 @@ func New() *StringManager {
 
 type StringManager struct {
 	lang    int
+	cp1251  bool   // CSF file uses CP-1251 instead of UTF-16
+	unk16   uint32 // unknown CSF header field at offset 16, preserved when writing
 	entries []Entry
 	byID    map[ID]*Entry
 }
@@ -46,6 +50,16 @@ func (sm *StringManager) Lang() int {
 	return sm.lang
 }
 
+// CP1251 reports whether CSF files are written in CP-1251 instead of UTF-16.
+func (sm *StringManager) CP1251() bool {
+	return sm.cp1251
+}
+
+// SetCP1251 enables CP-1251 encoding for CSF files instead of UTF-16, as used by the Russian version of Nox.
+func (sm *StringManager) SetCP1251(v bool) {
+	sm.cp1251 = v
+}
+
 func (sm *StringManager) Get(id ID) (Entry, bool) {
 	id = ID(strings.ToLower(string(id)))
 	ent := sm.byID[id]
@@ -128,7 +142,7 @@ func (sm *StringManager) ReadFile(path string) error {
 	case ".csf":
 		return sm.ReadCSF(path)
 	default:
-		if err := sm.readSTRFile(path); err == nil {
+		if err := sm.ReadSTR(path); err == nil {
 			return nil
 		} else if !os.IsNotExist(err) {
 			return err
@@ -136,7 +150,3 @@ func (sm *StringManager) ReadFile(path string) error {
 		return sm.ReadCSF(name + ".csf")
 	}
 }
-
-func (sm *StringManager) readSTRFile(path string) error {
-	return os.ErrNotExist // TODO: support this legacy format if we ever find a need for that
-}
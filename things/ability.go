@@ -1,5 +1,77 @@
 package things
 
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/noxworld-dev/opennox-lib/strman"
+)
+
+// Ability is a warrior ability definition from ABIL section.
+type Ability struct {
+	ID          string    `json:"name" yaml:"name"`
+	Delay       byte      `json:"delay" yaml:"delay"`
+	Icon        *ImageRef `json:"icon,omitempty" yaml:"icon,omitempty"`
+	IconEnabled *ImageRef `json:"icon_enabled,omitempty" yaml:"icon_enabled,omitempty"`
+	IconActive  *ImageRef `json:"icon_active,omitempty" yaml:"icon_active,omitempty"`
+	Title       strman.ID `json:"title,omitempty" yaml:"title,omitempty"`
+	Desc        strman.ID `json:"desc,omitempty" yaml:"desc,omitempty"`
+	CastSound   string    `json:"cast_sound,omitempty" yaml:"cast_sound,omitempty"`
+	OnSound     string    `json:"on_sound,omitempty" yaml:"on_sound,omitempty"`
+	OffSound    string    `json:"off_sound,omitempty" yaml:"off_sound,omitempty"`
+}
+
+func ReadAbilitiesYAML(path string) ([]Ability, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out []Ability
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var a Ability
+		err := dec.Decode(&a)
+		if err == io.EOF {
+			return out, nil
+		} else if err != nil {
+			return out, err
+		}
+		out = append(out, a)
+	}
+}
+
+func WriteAbilitiesYAML(path string, list []Ability) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := yaml.NewEncoder(f)
+	for _, a := range list {
+		if err := enc.Encode(a); err != nil {
+			return err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (f *Reader) ReadAbilities() ([]Ability, error) {
+	if err := f.seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	ok, err := f.skipUntil("ABIL")
+	if !ok {
+		return nil, err
+	}
+	return f.readABIL()
+}
+
 func (f *Reader) skipABIL() error {
 	n, err := f.readU32()
 	if err != nil {
@@ -37,3 +109,84 @@ func (f *Reader) skipABIL() error {
 	}
 	return nil
 }
+
+func (f *Reader) readABIL() ([]Ability, error) {
+	n, err := f.readU32()
+	if err != nil {
+		return nil, err
+	} else if n <= 0 {
+		return nil, nil
+	}
+	out := make([]Ability, 0, n)
+	for i := 0; i < int(n); i++ {
+		id, err := f.readString8()
+		if err != nil {
+			return out, err
+		}
+		delay, err := f.readU8()
+		if err != nil {
+			return out, err
+		}
+		var icons [3]*ImageRef
+		for j := range icons {
+			icons[j], err = f.readImageRef()
+			if err != nil {
+				return out, err
+			}
+		}
+		title, err := f.readString8()
+		if err != nil {
+			return out, err
+		}
+		desc, err := f.readString16()
+		if err != nil {
+			return out, err
+		}
+		var sounds [3]string
+		for j := range sounds {
+			s, err := f.readString8()
+			if err != nil {
+				return out, err
+			}
+			if s == "NULL" {
+				s = ""
+			}
+			sounds[j] = s
+		}
+		out = append(out, Ability{
+			ID:          id,
+			Delay:       delay,
+			Icon:        icons[0],
+			IconEnabled: icons[1],
+			IconActive:  icons[2],
+			Title:       strman.ID(title),
+			Desc:        strman.ID(desc),
+			CastSound:   sounds[0],
+			OnSound:     sounds[1],
+			OffSound:    sounds[2],
+		})
+	}
+	return out, nil // no END here
+}
+
+// WriteAbilities writes ability definitions as ABIL section.
+func (f *Writer) WriteAbilities(list []Ability) error {
+	if len(list) == 0 {
+		return f.err
+	}
+	f.writeSect("ABIL")
+	f.writeU32(uint32(len(list)))
+	for _, a := range list {
+		f.writeString8(a.ID)
+		f.writeU8(a.Delay)
+		f.writeImageRef(a.Icon)
+		f.writeImageRef(a.IconEnabled)
+		f.writeImageRef(a.IconActive)
+		f.writeString8(string(a.Title))
+		f.writeString16(string(a.Desc))
+		f.writeStringNull(a.CastSound)
+		f.writeStringNull(a.OnSound)
+		f.writeStringNull(a.OffSound)
+	}
+	return f.err
+}
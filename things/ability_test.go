@@ -0,0 +1,56 @@
+package things
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestReadAbilities(t *testing.T) {
+	exp := []Ability{
+		{
+			ID:          "ABILITY_BERSERKER_CHARGE",
+			Delay:       10,
+			Icon:        &ImageRef{Ind: 1},
+			IconEnabled: &ImageRef{Ind: 2},
+			IconActive:  &ImageRef{Ind2: 1, Name: "BerserkerActive"},
+			Title:       "thing.db:BerserkerCharge",
+			Desc:        "thing.db:BerserkerChargeDescription",
+			CastSound:   "BerserkerChargeInvoke",
+			OffSound:    "BerserkerChargeOff",
+		},
+	}
+	var b testFile
+	b.sect("ABIL")
+	b.u32(uint32(len(exp)))
+	for _, a := range exp {
+		b.str8(a.ID)
+		b.u8(a.Delay)
+		b.imgRef(*a.Icon)
+		b.imgRef(*a.IconEnabled)
+		b.imgRef(*a.IconActive)
+		b.str8(string(a.Title))
+		b.u16(uint16(len(a.Desc)))
+		b.WriteString(string(a.Desc))
+		b.str8(a.CastSound)
+		b.str8("NULL")
+		b.str8(a.OffSound)
+	}
+
+	r := b.open(t)
+	list, err := r.ReadAbilities()
+	must.NoError(t, err)
+	must.Eq(t, exp, list)
+
+	data, err := r.ReadAll()
+	must.NoError(t, err)
+	must.Eq(t, exp, data.Abilities)
+
+	path := filepath.Join(t.TempDir(), "abilities.yml")
+	err = WriteAbilitiesYAML(path, list)
+	must.NoError(t, err)
+	list, err = ReadAbilitiesYAML(path)
+	must.NoError(t, err)
+	must.Eq(t, exp, list)
+}
@@ -1,5 +1,36 @@
 package things
 
+import "io"
+
+// Audio is a sound definition from AUD section.
+// Sound names referenced by Thing, Spell and Wall resolve to these definitions.
+type Audio struct {
+	Name   string `json:"name"`
+	Volume uint32 `json:"volume"`
+	Flags  uint32 `json:"flags,omitempty"`
+	Unk1   byte
+	// Samples lists sample file names for this sound. The game picks one of them randomly.
+	Samples []string `json:"samples,omitempty"`
+}
+
+func (f *Reader) ReadAudio() ([]Audio, error) {
+	if err := f.seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var out []Audio
+	for {
+		ok, err := f.skipUntil("AUD ")
+		if !ok {
+			return out, err
+		}
+		list, err := f.readAUD()
+		if err != nil {
+			return out, err
+		}
+		out = append(out, list...)
+	}
+}
+
 func (f *Reader) skipAUD() error {
 	n, err := f.readI32()
 	if err != nil {
@@ -29,3 +60,85 @@ func (f *Reader) skipAUD() error {
 	}
 	return nil // yes, there's no END
 }
+
+// readStrings8 reads a list of strings terminated by an empty string.
+func (f *Reader) readStrings8() ([]string, error) {
+	var out []string
+	for {
+		s, err := f.readString8()
+		if err != nil {
+			return out, err
+		} else if s == "" {
+			return out, nil
+		}
+		out = append(out, s)
+	}
+}
+
+func (f *Reader) readAUD() ([]Audio, error) {
+	n, err := f.readI32()
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	out := make([]Audio, 0, n)
+	for i := 0; i < int(n); i++ {
+		name, err := f.readString8()
+		if err != nil {
+			return out, err
+		}
+		vol, err := f.readU32()
+		if err != nil {
+			return out, err
+		}
+		flags, err := f.readU32()
+		if err != nil {
+			return out, err
+		}
+		unk1, err := f.readU8()
+		if err != nil {
+			return out, err
+		}
+		samples, err := f.readStrings8()
+		if err != nil {
+			return out, err
+		}
+		out = append(out, Audio{
+			Name:    name,
+			Volume:  vol,
+			Flags:   flags,
+			Unk1:    unk1,
+			Samples: samples,
+		})
+	}
+	return out, nil // yes, there's no END
+}
+
+// AudioByName finds a sound definition by name. It returns nil if the sound is not defined.
+func (d *Data) AudioByName(name string) *Audio {
+	for i := range d.Audio {
+		if d.Audio[i].Name == name {
+			return &d.Audio[i]
+		}
+	}
+	return nil
+}
+
+// WriteAudio writes sound definitions as AUD section.
+func (f *Writer) WriteAudio(list []Audio) error {
+	if len(list) == 0 {
+		return f.err
+	}
+	f.writeSect("AUD ")
+	f.writeI32(int32(len(list)))
+	for _, a := range list {
+		f.writeString8(a.Name)
+		f.writeU32(a.Volume)
+		f.writeU32(a.Flags)
+		f.writeU8(a.Unk1)
+		f.writeStrings8(a.Samples)
+	}
+	return f.err
+}
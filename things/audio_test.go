@@ -0,0 +1,66 @@
+package things
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestReadAudio(t *testing.T) {
+	exp := []Audio{
+		{Name: "FireballCast", Volume: 100, Flags: 0x1, Unk1: 2, Samples: []string{"FBCast1.wav", "FBCast2.wav"}},
+		{Name: "Silence", Volume: 50},
+	}
+	expEv := AudioEvent{
+		Name: "Footstep",
+		Params: []AudioEventParam{
+			{Type: 1, Data: []byte{5}},
+			{Type: AudioEventSounds, Sounds: []string{"FootstepDirt", "FootstepGrass"}},
+			{Type: 8, Data: []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		},
+	}
+	var b testFile
+	b.sect("AUD ")
+	b.u32(uint32(len(exp)))
+	for _, a := range exp {
+		b.str8(a.Name)
+		b.u32(a.Volume)
+		b.u32(a.Flags)
+		b.u8(a.Unk1)
+		for _, s := range a.Samples {
+			b.str8(s)
+		}
+		b.u8(0)
+	}
+	b.sect("AVNT")
+	b.str8(expEv.Name)
+	for _, p := range expEv.Params {
+		b.u8(byte(p.Type))
+		b.Write(p.Data)
+		if p.Type == AudioEventSounds {
+			for _, s := range p.Sounds {
+				b.str8(s)
+			}
+			b.u8(0)
+		}
+	}
+	b.u8(0)
+
+	r := b.open(t)
+	list, err := r.ReadAudio()
+	must.NoError(t, err)
+	must.Eq(t, exp, list)
+
+	evs, err := r.ReadAudioEvents()
+	must.NoError(t, err)
+	must.Eq(t, []AudioEvent{expEv}, evs)
+
+	data, err := r.ReadAll()
+	must.NoError(t, err)
+	must.Eq(t, exp, data.Audio)
+	must.Eq(t, &data.Audio[0], data.AudioByName("FireballCast"))
+	must.Nil(t, data.AudioByName("Unknown"))
+	ev := data.AudioEventByName("Footstep")
+	must.NotNil(t, ev)
+	must.Eq(t, []string{"FootstepDirt", "FootstepGrass"}, ev.Sounds())
+}
@@ -1,6 +1,75 @@
 package things
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
+
+// AudioEventParamType is a type of AudioEventParam.
+type AudioEventParamType byte
+
+const (
+	// AudioEventSounds is a parameter that lists sound names for the event.
+	AudioEventSounds = AudioEventParamType(7)
+)
+
+// AudioEventParam is a single parameter of AudioEvent.
+type AudioEventParam struct {
+	Type AudioEventParamType `json:"type"`
+	// Data contains raw parameter value. It's set for all types except AudioEventSounds.
+	Data []byte `json:"data,omitempty"`
+	// Sounds is set for AudioEventSounds type.
+	Sounds []string `json:"sounds,omitempty"`
+}
+
+// AudioEvent is an audio event definition from AVNT section.
+type AudioEvent struct {
+	Name   string            `json:"name"`
+	Params []AudioEventParam `json:"params,omitempty"`
+}
+
+// Sounds returns all sound names referenced by this event.
+func (e *AudioEvent) Sounds() []string {
+	var out []string
+	for _, p := range e.Params {
+		if p.Type == AudioEventSounds {
+			out = append(out, p.Sounds...)
+		}
+	}
+	return out
+}
+
+func (f *Reader) ReadAudioEvents() ([]AudioEvent, error) {
+	if err := f.seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var out []AudioEvent
+	for {
+		ok, err := f.skipUntil("AVNT")
+		if !ok {
+			return out, err
+		}
+		ev, err := f.readAVNT()
+		if err != nil {
+			return out, err
+		}
+		out = append(out, *ev)
+	}
+}
+
+// audioEventParamSize returns the size of AudioEventParam data for a given type.
+// It returns -1 for AudioEventSounds and unknown types.
+func audioEventParamSize(typ AudioEventParamType) int {
+	switch typ {
+	case 1, 2, 3, 4, 5:
+		return 1
+	case 6, 9, 10:
+		return 2
+	case 8:
+		return 8
+	}
+	return -1
+}
 
 func (f *Reader) skipAVNT() error {
 	if err := f.skipBytes8(); err != nil {
@@ -43,3 +112,81 @@ func (f *Reader) skipAVNT() error {
 		}
 	}
 }
+
+func (f *Reader) readAVNT() (*AudioEvent, error) {
+	name, err := f.readString8()
+	if err != nil {
+		return nil, err
+	}
+	ev := &AudioEvent{Name: name}
+	for {
+		v, err := f.readU8()
+		if err != nil {
+			return nil, err
+		}
+		typ := AudioEventParamType(v)
+		if typ == 0 {
+			return ev, nil
+		}
+		if typ == AudioEventSounds {
+			list, err := f.readStrings8()
+			if err != nil {
+				return nil, err
+			}
+			ev.Params = append(ev.Params, AudioEventParam{Type: typ, Sounds: list})
+			continue
+		}
+		sz := audioEventParamSize(typ)
+		if sz < 0 {
+			return nil, fmt.Errorf("unknown AVNT type: %d", typ)
+		}
+		data := make([]byte, sz)
+		if _, err := f.read(data); err != nil {
+			return nil, err
+		}
+		ev.Params = append(ev.Params, AudioEventParam{Type: typ, Data: data})
+	}
+}
+
+// AudioEventByName finds an audio event definition by name. It returns nil if the event is not defined.
+func (d *Data) AudioEventByName(name string) *AudioEvent {
+	for i := range d.AudioEvents {
+		if d.AudioEvents[i].Name == name {
+			return &d.AudioEvents[i]
+		}
+	}
+	return nil
+}
+
+// WriteAudioEvents writes each audio event definition as a separate AVNT section.
+func (f *Writer) WriteAudioEvents(list []AudioEvent) error {
+	for i := range list {
+		f.writeAVNT(&list[i])
+	}
+	return f.err
+}
+
+func (f *Writer) writeAVNT(ev *AudioEvent) {
+	f.writeSect("AVNT")
+	f.writeString8(ev.Name)
+	for _, p := range ev.Params {
+		if p.Type == 0 {
+			f.setErr(fmt.Errorf("audio event %q: invalid param type", ev.Name))
+			return
+		}
+		f.writeU8(byte(p.Type))
+		if p.Type == AudioEventSounds {
+			f.writeStrings8(p.Sounds)
+			continue
+		}
+		if sz := audioEventParamSize(p.Type); sz < 0 {
+			f.setErr(fmt.Errorf("audio event %q: unknown param type: %d", ev.Name, p.Type))
+			return
+		} else if sz != len(p.Data) {
+			f.setErr(fmt.Errorf("audio event %q: expected %d bytes for param type %d, got %d", ev.Name, sz, p.Type, len(p.Data)))
+			return
+		}
+		f.write(p.Data)
+	}
+	f.writeU8(0)
+}
@@ -1,5 +1,52 @@
 package things
 
+import (
+	"fmt"
+	"io"
+)
+
+// Edge is a tile edge definition from EDGE section.
+// Edges are drawn on top of floor tiles to blend transitions between different floor types.
+type Edge struct {
+	Name  string `json:"name"`
+	Flags uint32 `json:"flags,omitempty"`
+	Unk1  uint32
+	Unk2  uint32
+	Unk3  byte
+	Unk4  byte
+	Unk5  byte
+	// Variations is the number of image pairs for each edge direction.
+	Variations byte `json:"variations"`
+	// Sides contains image variants for straight edge directions.
+	Sides []EdgeDirection `json:"sides,omitempty"`
+	// Corners contains image variants for corner edge directions.
+	Corners []EdgeDirection `json:"corners,omitempty"`
+}
+
+// EdgeDirection contains image variants for a single edge direction.
+type EdgeDirection struct {
+	// Variants contains 2*Edge.Variations image references.
+	Variants []ImageRef `json:"variants,omitempty"`
+}
+
+func (f *Reader) ReadEdges() ([]Edge, error) {
+	if err := f.seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var out []Edge
+	for {
+		ok, err := f.skipUntil("EDGE")
+		if !ok {
+			return out, err
+		}
+		e, err := f.readEDGE()
+		if err != nil {
+			return out, err
+		}
+		out = append(out, *e)
+	}
+}
+
 func (f *Reader) skipEDGE() error {
 	if err := f.skip(4); err != nil {
 		return err
@@ -34,3 +81,122 @@ func (f *Reader) skipEDGE() error {
 	}
 	return f.checkEND()
 }
+
+func (f *Reader) readEdgeDirections(n int, vars int) ([]EdgeDirection, error) {
+	out := make([]EdgeDirection, 0, n)
+	for i := 0; i < n; i++ {
+		d := EdgeDirection{Variants: make([]ImageRef, 0, vars)}
+		for j := 0; j < vars; j++ {
+			ref, err := f.readImageRef()
+			if err != nil {
+				return nil, err
+			}
+			d.Variants = append(d.Variants, *ref)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (f *Reader) readEDGE() (*Edge, error) {
+	flags, err := f.readU32()
+	if err != nil {
+		return nil, err
+	}
+	name, err := f.readString8()
+	if err != nil {
+		return nil, err
+	}
+	unk1, err := f.readU32()
+	if err != nil {
+		return nil, err
+	}
+	unk2, err := f.readU32()
+	if err != nil {
+		return nil, err
+	}
+	unk3, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	vars, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	unk4, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	unk5, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	nsides, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	ncorners, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	e := &Edge{
+		Name:       name,
+		Flags:      flags,
+		Unk1:       unk1,
+		Unk2:       unk2,
+		Unk3:       unk3,
+		Unk4:       unk4,
+		Unk5:       unk5,
+		Variations: vars,
+	}
+	e.Sides, err = f.readEdgeDirections(int(nsides), 2*int(vars))
+	if err != nil {
+		return nil, err
+	}
+	e.Corners, err = f.readEdgeDirections(int(ncorners), 2*int(vars))
+	if err != nil {
+		return nil, err
+	}
+	return e, f.checkEND()
+}
+
+// WriteEdges writes each edge definition as a separate EDGE section.
+func (f *Writer) WriteEdges(list []Edge) error {
+	for i := range list {
+		f.writeEDGE(&list[i])
+	}
+	return f.err
+}
+
+func (f *Writer) writeEdgeDirections(e *Edge, list []EdgeDirection) {
+	for _, d := range list {
+		if n := 2 * int(e.Variations); n != len(d.Variants) {
+			f.setErr(fmt.Errorf("edge %q: expected %d variants, got %d", e.Name, n, len(d.Variants)))
+			return
+		}
+		for _, ref := range d.Variants {
+			f.writeImageRef(&ref)
+		}
+	}
+}
+
+func (f *Writer) writeEDGE(e *Edge) {
+	if len(e.Sides) > 0xff || len(e.Corners) > 0xff {
+		f.setErr(fmt.Errorf("edge %q: too many directions", e.Name))
+		return
+	}
+	f.writeSect("EDGE")
+	f.writeU32(e.Flags)
+	f.writeString8(e.Name)
+	f.writeU32(e.Unk1)
+	f.writeU32(e.Unk2)
+	f.writeU8(e.Unk3)
+	f.writeU8(e.Variations)
+	f.writeU8(e.Unk4)
+	f.writeU8(e.Unk5)
+	f.writeU8(byte(len(e.Sides)))
+	f.writeU8(byte(len(e.Corners)))
+	f.writeEdgeDirections(e, e.Sides)
+	f.writeEdgeDirections(e, e.Corners)
+	f.writeSect("END ")
+}
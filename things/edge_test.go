@@ -0,0 +1,54 @@
+package things
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestReadEdges(t *testing.T) {
+	exp := Edge{
+		Name:       "GrassEdge",
+		Flags:      0x1,
+		Unk1:       1,
+		Unk2:       2,
+		Unk3:       3,
+		Unk4:       4,
+		Unk5:       5,
+		Variations: 1,
+		Sides: []EdgeDirection{
+			{Variants: []ImageRef{{Ind: 1}, {Ind: 2}}},
+			{Variants: []ImageRef{{Ind: 3}, {Ind2: 1, Name: "GrassEdgeNW"}}},
+		},
+		Corners: []EdgeDirection{
+			{Variants: []ImageRef{{Ind: 5}, {Ind: 6}}},
+		},
+	}
+	var b testFile
+	b.sect("EDGE")
+	b.u32(exp.Flags)
+	b.str8(exp.Name)
+	b.u32(exp.Unk1)
+	b.u32(exp.Unk2)
+	b.u8(exp.Unk3)
+	b.u8(exp.Variations)
+	b.u8(exp.Unk4)
+	b.u8(exp.Unk5)
+	b.u8(byte(len(exp.Sides)))
+	b.u8(byte(len(exp.Corners)))
+	for _, d := range append(exp.Sides, exp.Corners...) {
+		for _, ref := range d.Variants {
+			b.imgRef(ref)
+		}
+	}
+	b.sect("END ")
+
+	r := b.open(t)
+	list, err := r.ReadEdges()
+	must.NoError(t, err)
+	must.Eq(t, []Edge{exp}, list)
+
+	data, err := r.ReadAll()
+	must.NoError(t, err)
+	must.Eq(t, []Edge{exp}, data.Edges)
+}
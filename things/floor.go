@@ -1,5 +1,46 @@
 package things
 
+import (
+	"fmt"
+	"io"
+)
+
+// Floor is a floor tile definition from FLOR section.
+type Floor struct {
+	Name  string `json:"name"`
+	Flags uint32 `json:"flags,omitempty"`
+	Color RGB    `json:"color"`
+	Unk1  uint32
+	Unk2  uint32
+	Unk3  byte
+	Unk4  byte
+	// VariationsX and VariationsY define a grid of tile variations used for this floor type.
+	VariationsX byte `json:"variations_x"`
+	VariationsY byte `json:"variations_y"`
+	// Frames is the number of animation frames for each variation.
+	Frames byte `json:"frames"`
+	// Images contains VariationsX*VariationsY*Frames image references.
+	Images []ImageRef `json:"images,omitempty"`
+}
+
+func (f *Reader) ReadFloors() ([]Floor, error) {
+	if err := f.seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var out []Floor
+	for {
+		ok, err := f.skipUntil("FLOR")
+		if !ok {
+			return out, err
+		}
+		fl, err := f.readFLOR()
+		if err != nil {
+			return out, err
+		}
+		out = append(out, *fl)
+	}
+}
+
 func (f *Reader) skipFLOR() error {
 	if err := f.skip(4); err != nil {
 		return err
@@ -33,3 +74,98 @@ func (f *Reader) skipFLOR() error {
 	}
 	return f.checkEND()
 }
+
+func (f *Reader) readFLOR() (*Floor, error) {
+	flags, err := f.readU32()
+	if err != nil {
+		return nil, err
+	}
+	name, err := f.readString8()
+	if err != nil {
+		return nil, err
+	}
+	var rgb [3]byte
+	if _, err = f.read(rgb[:]); err != nil {
+		return nil, err
+	}
+	unk1, err := f.readU32()
+	if err != nil {
+		return nil, err
+	}
+	unk2, err := f.readU32()
+	if err != nil {
+		return nil, err
+	}
+	unk3, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	nx, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	ny, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	nf, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	unk4, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	n := int(nx) * int(ny) * int(nf)
+	fl := &Floor{
+		Name:        name,
+		Flags:       flags,
+		Color:       RGB{R: rgb[0], G: rgb[1], B: rgb[2]},
+		Unk1:        unk1,
+		Unk2:        unk2,
+		Unk3:        unk3,
+		Unk4:        unk4,
+		VariationsX: nx,
+		VariationsY: ny,
+		Frames:      nf,
+		Images:      make([]ImageRef, 0, n),
+	}
+	for i := 0; i < n; i++ {
+		ref, err := f.readImageRef()
+		if err != nil {
+			return nil, err
+		}
+		fl.Images = append(fl.Images, *ref)
+	}
+	return fl, f.checkEND()
+}
+
+// WriteFloors writes each floor definition as a separate FLOR section.
+func (f *Writer) WriteFloors(list []Floor) error {
+	for i := range list {
+		f.writeFLOR(&list[i])
+	}
+	return f.err
+}
+
+func (f *Writer) writeFLOR(fl *Floor) {
+	if n := int(fl.VariationsX) * int(fl.VariationsY) * int(fl.Frames); n != len(fl.Images) {
+		f.setErr(fmt.Errorf("floor %q: expected %d images, got %d", fl.Name, n, len(fl.Images)))
+		return
+	}
+	f.writeSect("FLOR")
+	f.writeU32(fl.Flags)
+	f.writeString8(fl.Name)
+	f.write([]byte{fl.Color.R, fl.Color.G, fl.Color.B})
+	f.writeU32(fl.Unk1)
+	f.writeU32(fl.Unk2)
+	f.writeU8(fl.Unk3)
+	f.writeU8(fl.VariationsX)
+	f.writeU8(fl.VariationsY)
+	f.writeU8(fl.Frames)
+	f.writeU8(fl.Unk4)
+	for _, ref := range fl.Images {
+		f.writeImageRef(&ref)
+	}
+	f.writeSect("END ")
+}
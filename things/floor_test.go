@@ -0,0 +1,53 @@
+package things
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestReadFloors(t *testing.T) {
+	exp := Floor{
+		Name:        "Dirt",
+		Flags:       0x2,
+		Color:       RGB{R: 10, G: 20, B: 30},
+		Unk1:        1,
+		Unk2:        2,
+		Unk3:        3,
+		Unk4:        4,
+		VariationsX: 2,
+		VariationsY: 1,
+		Frames:      1,
+		Images: []ImageRef{
+			{Ind: 100},
+			{Ind2: 1, Name: "DirtTile"},
+		},
+	}
+	var b testFile
+	b.sect("FLOR")
+	b.u32(exp.Flags)
+	b.str8(exp.Name)
+	b.u8(exp.Color.R)
+	b.u8(exp.Color.G)
+	b.u8(exp.Color.B)
+	b.u32(exp.Unk1)
+	b.u32(exp.Unk2)
+	b.u8(exp.Unk3)
+	b.u8(exp.VariationsX)
+	b.u8(exp.VariationsY)
+	b.u8(exp.Frames)
+	b.u8(exp.Unk4)
+	for _, ref := range exp.Images {
+		b.imgRef(ref)
+	}
+	b.sect("END ")
+
+	r := b.open(t)
+	list, err := r.ReadFloors()
+	must.NoError(t, err)
+	must.Eq(t, []Floor{exp}, list)
+
+	data, err := r.ReadAll()
+	must.NoError(t, err)
+	must.Eq(t, []Floor{exp}, data.Floors)
+}
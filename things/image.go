@@ -88,11 +88,17 @@ type ImageRef struct {
 	Name string `json:"name,omitempty" yaml:"name,omitempty"`
 }
 
+// imageRefJSON is a JSON representation of a named ImageRef.
+type imageRefJSON struct {
+	Ind  int    `json:"ind,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
 func (ii ImageRef) MarshalJSON() ([]byte, error) {
 	if ii.Ind != 0 {
 		return json.Marshal(ii.Ind)
 	}
-	return json.Marshal(ii)
+	return json.Marshal(imageRefJSON{Ind: ii.Ind2, Name: ii.Name})
 }
 
 func (ii *ImageRef) UnmarshalJSON(p []byte) error {
@@ -102,10 +108,7 @@ func (ii *ImageRef) UnmarshalJSON(p []byte) error {
 		ii.Ind = ind
 		return nil
 	}
-	var v struct {
-		Ind  int    `json:"ind,omitempty"`
-		Name string `json:"name,omitempty"`
-	}
+	var v imageRefJSON
 	if err := json.Unmarshal(p, &v); err != nil {
 		return err
 	}
@@ -114,6 +117,40 @@ func (ii *ImageRef) UnmarshalJSON(p []byte) error {
 	return nil
 }
 
+// ImageByName finds an image definition by name. It returns nil if the image is not defined.
+func (d *Data) ImageByName(name string) *Image {
+	for i := range d.Images {
+		if d.Images[i].Name == name {
+			return &d.Images[i]
+		}
+	}
+	return nil
+}
+
+// ImageIndex resolves ImageRef to an image index in video.bag.
+// Named references are resolved using image definitions from IMAG section. For animations, Ind2 selects the frame.
+func (d *Data) ImageIndex(ref ImageRef) (int, bool) {
+	if ref.Name == "" {
+		return ref.Ind, ref.Ind >= 0
+	}
+	img := d.ImageByName(ref.Name)
+	switch {
+	case img == nil:
+		return 0, false
+	case img.Img != nil:
+		if img.Img.Name != "" {
+			return 0, false // avoid loops
+		}
+		return img.Img.Ind, true
+	case img.Ani != nil:
+		if ref.Ind2 < 0 || ref.Ind2 >= len(img.Ani.Frames) || img.Ani.Frames[ref.Ind2].Name != "" {
+			return 0, false
+		}
+		return img.Ani.Frames[ref.Ind2].Ind, true
+	}
+	return 0, false
+}
+
 func (f *Reader) readImageRefs8() ([]ImageRef, error) {
 	n, err := f.readU8()
 	if err != nil {
@@ -282,3 +319,59 @@ func (f *Reader) skipIMAG() error {
 	}
 	return nil
 }
+
+func (f *Writer) writeImageRefs8(list []ImageRef) {
+	if len(list) > 0xff {
+		f.setErr(fmt.Errorf("too many images: %d", len(list)))
+		return
+	}
+	f.writeU8(byte(len(list)))
+	for _, ref := range list {
+		f.writeImageRef(&ref)
+	}
+}
+
+func (f *Writer) writeImageRef(ref *ImageRef) {
+	if ref == nil {
+		f.writeI32(0)
+		return
+	}
+	if ref.Name == "" && ref.Ind2 == 0 {
+		f.writeI32(int32(ref.Ind))
+		return
+	}
+	if ref.Ind2 < 0 || ref.Ind2 > 0xff {
+		f.setErr(fmt.Errorf("invalid image index: %d", ref.Ind2))
+		return
+	}
+	f.writeI32(-1)
+	f.writeU8(byte(ref.Ind2))
+	f.writeString8(ref.Name)
+}
+
+func (f *Writer) writeImage(img *Image) {
+	f.writeString8(img.Name)
+	switch {
+	case img.Img != nil:
+		f.writeU8(1)
+		f.writeImageRef(img.Img)
+	case img.Ani != nil:
+		f.writeU8(2)
+		f.writeAnimation(img.Ani)
+	default:
+		f.setErr(fmt.Errorf("image %q has neither image nor animation", img.Name))
+	}
+}
+
+// WriteImages writes image definitions as IMAG section.
+func (f *Writer) WriteImages(list []Image) error {
+	if len(list) == 0 {
+		return f.err
+	}
+	f.writeSect("IMAG")
+	f.writeU32(uint32(len(list)))
+	for i := range list {
+		f.writeImage(&list[i])
+	}
+	return f.err
+}
@@ -0,0 +1,272 @@
+package things
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+var reflThingJSON = make(map[string]struct{})
+
+func init() {
+	for i := 0; i < reflThing.NumField(); i++ {
+		f := reflThing.Field(i)
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		reflThingJSON[name] = struct{}{}
+	}
+}
+
+// Patch is a set of changes for thing.bin data, usually loaded from a YAML file. See Data.ApplyPatches.
+//
+// Example:
+//
+//	things:
+//	  - name: MyRock
+//	    inherit: Rock
+//	    set:
+//	      health: 100
+//	      flags: [NO_UPDATE, SHADOW]
+//	  - name: Bat
+//	    set:
+//	      speed: 3
+//	  - name: OldRock
+//	    delete: true
+type Patch struct {
+	// Name of the patch used in error and conflict reports. ReadPatchYAML sets it to the file path.
+	Name   string       `json:"name,omitempty" yaml:"name,omitempty"`
+	Things []ThingPatch `json:"things,omitempty" yaml:"things,omitempty"`
+}
+
+// ThingPatch describes changes for a single Thing.
+//
+// If Thing with a given name doesn't exist, it will be added. Inherit can be set to copy all fields from another Thing.
+// Set contains field overrides, keys are the same as in Thing JSON. Null value resets the field.
+type ThingPatch struct {
+	Name    string         `json:"name" yaml:"name"`
+	Inherit string         `json:"inherit,omitempty" yaml:"inherit,omitempty"`
+	Delete  bool           `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Set     map[string]any `json:"set,omitempty" yaml:"set,omitempty"`
+}
+
+// PatchConflict is reported when two patches change the same Thing field to a different value.
+type PatchConflict struct {
+	Thing string `json:"thing"`
+	// Field is a JSON name of the Thing field. It is empty if the whole Thing was deleted by one of the patches.
+	Field string `json:"field,omitempty"`
+	// Prev is the name of a patch that changed the field first.
+	Prev string `json:"prev"`
+	// Patch is the name of a patch that overrides the change.
+	Patch string `json:"patch"`
+}
+
+func (c PatchConflict) String() string {
+	if c.Field == "" {
+		return fmt.Sprintf("thing %q: %s overrides %s", c.Thing, c.Patch, c.Prev)
+	}
+	return fmt.Sprintf("thing %q: field %q: %s overrides %s", c.Thing, c.Field, c.Patch, c.Prev)
+}
+
+// ReadPatchYAML reads a single Patch from YAML file.
+func ReadPatchYAML(path string) (*Patch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Patch
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Name == "" {
+		p.Name = path
+	}
+	return &p, nil
+}
+
+// patchChange records which patch made a change, and the value it set.
+type patchChange struct {
+	patch string
+	value string
+}
+
+type patchState struct {
+	conflicts []PatchConflict
+	// changes made by patches to things and thing fields; empty field key is used for the whole thing
+	changes map[string]map[string]patchChange
+}
+
+func (s *patchState) record(patch, thing, field string, value any) {
+	val, _ := json.Marshal(value)
+	fields := s.changes[thing]
+	if fields == nil {
+		fields = make(map[string]patchChange)
+		s.changes[thing] = fields
+	}
+	ch := patchChange{patch: patch, value: string(val)}
+	if field == "" {
+		// the whole thing was replaced or removed, which conflicts with all previous changes from other patches
+		keys := maps.Keys(fields)
+		slices.Sort(keys)
+		for _, f := range keys {
+			if prev := fields[f]; prev.patch != patch {
+				s.conflicts = append(s.conflicts, PatchConflict{Thing: thing, Field: f, Prev: prev.patch, Patch: patch})
+			}
+		}
+		s.changes[thing] = map[string]patchChange{"": ch}
+		return
+	}
+	if prev, ok := fields[""]; ok && prev.patch != patch && prev.value == "null" {
+		s.conflicts = append(s.conflicts, PatchConflict{Thing: thing, Prev: prev.patch, Patch: patch})
+	} else if prev, ok := fields[field]; ok && prev.patch != patch && prev.value != ch.value {
+		s.conflicts = append(s.conflicts, PatchConflict{Thing: thing, Field: field, Prev: prev.patch, Patch: patch})
+	}
+	fields[field] = ch
+}
+
+// ApplyPatches applies patches to Data in order. Later patches override changes made by previous ones.
+// All such overrides are reported as conflicts. The result is validated with Validate.
+//
+// Note that adding or deleting things changes object type IDs of all the following things.
+func (d *Data) ApplyPatches(list ...*Patch) ([]PatchConflict, error) {
+	s := &patchState{changes: make(map[string]map[string]patchChange)}
+	for i, p := range list {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("patch %d", i+1)
+		}
+		for _, tp := range p.Things {
+			if err := d.applyThingPatch(s, name, &tp); err != nil {
+				return s.conflicts, fmt.Errorf("%s: thing %q: %w", name, tp.Name, err)
+			}
+		}
+	}
+	return s.conflicts, d.Validate()
+}
+
+func (d *Data) applyThingPatch(s *patchState, patch string, tp *ThingPatch) error {
+	if tp.Name == "" {
+		return errors.New("name must be set")
+	}
+	if _, ok := tp.Set["name"]; ok {
+		return errors.New("name cannot be changed, use inherit and delete instead")
+	}
+	for key := range tp.Set {
+		if _, ok := reflThingJSON[key]; !ok {
+			return fmt.Errorf("unsupported field: %q", key)
+		}
+	}
+	if tp.Delete {
+		if tp.Inherit != "" || len(tp.Set) != 0 {
+			return errors.New("delete cannot be combined with other changes")
+		}
+		for i := range d.Things {
+			if d.Things[i].Name == tp.Name {
+				d.Things = append(d.Things[:i], d.Things[i+1:]...)
+				s.record(patch, tp.Name, "", nil)
+				return nil
+			}
+		}
+		return errors.New("thing is not defined")
+	}
+	th := d.ThingByName(tp.Name)
+	if tp.Inherit != "" {
+		if th != nil {
+			return errors.New("thing is already defined")
+		}
+		base := d.ThingByName(tp.Inherit)
+		if base == nil {
+			return fmt.Errorf("base thing %q is not defined", tp.Inherit)
+		}
+		nth, err := patchThing(base, nil)
+		if err != nil {
+			return err
+		}
+		nth.Name = tp.Name
+		d.Things = append(d.Things, *nth)
+		th = &d.Things[len(d.Things)-1]
+		s.record(patch, tp.Name, "", tp.Inherit)
+	} else if th == nil {
+		d.Things = append(d.Things, Thing{Name: tp.Name})
+		th = &d.Things[len(d.Things)-1]
+		s.record(patch, tp.Name, "", tp.Name)
+	}
+	if len(tp.Set) == 0 {
+		return nil
+	}
+	nth, err := patchThing(th, tp.Set)
+	if err != nil {
+		return err
+	}
+	*th = *nth
+	keys := maps.Keys(tp.Set)
+	slices.Sort(keys)
+	for _, key := range keys {
+		s.record(patch, tp.Name, key, tp.Set[key])
+	}
+	return nil
+}
+
+// patchThing returns a deep copy of the Thing with fields overridden from the set map.
+func patchThing(th *Thing, set map[string]any) (*Thing, error) {
+	data, err := json.Marshal(th)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for key, val := range set {
+		if val == nil {
+			delete(m, key)
+		} else {
+			m[key] = val
+		}
+	}
+	data, err = json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var out Thing
+	if err = json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Validate checks that Data is consistent and can be written to thing.bin.
+func (d *Data) Validate() error {
+	seen := make(map[string]struct{}, len(d.Things))
+	for i := range d.Things {
+		th := &d.Things[i]
+		if th.Name == "" {
+			return fmt.Errorf("thing %d: name must be set", i+1)
+		}
+		if _, ok := seen[th.Name]; ok {
+			return fmt.Errorf("thing %q: defined multiple times", th.Name)
+		}
+		seen[th.Name] = struct{}{}
+		if th.Extent != nil {
+			if _, err := formatExtent(th.Extent); err != nil {
+				return fmt.Errorf("thing %q: %w", th.Name, err)
+			}
+		}
+		if th.Draw != nil {
+			if _, _, err := encodeThingDraw(th.Draw); err != nil {
+				return fmt.Errorf("thing %q: %w", th.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package things
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func testPatchData() *Data {
+	return &Data{Things: []Thing{
+		{Name: "Rock", Health: intPtr(10), Flags: []Flag{"NO_UPDATE"}, Extent: Circle{R: 5}, Draw: StaticDraw{Img: ImageRef{Ind: 1}}},
+		{Name: "Bat", Speed: intPtr(2), OnXfer: &ProcFunc{Name: "MonsterXfer"}},
+		{Name: "Junk"},
+	}}
+}
+
+func TestReadPatchYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mod.yml")
+	err := os.WriteFile(path, []byte(`
+things:
+  - name: BigRock
+    inherit: Rock
+    set:
+      health: 100
+      flags: [NO_UPDATE, SHADOW]
+  - name: Bat
+    set:
+      speed: 5
+      on_xfer: null
+  - name: Junk
+    delete: true
+  - name: Gem
+    set:
+      class: [TREASURE]
+`), 0644)
+	must.NoError(t, err)
+	p, err := ReadPatchYAML(path)
+	must.NoError(t, err)
+	must.EqOp(t, path, p.Name)
+
+	d := testPatchData()
+	conflicts, err := d.ApplyPatches(p)
+	must.NoError(t, err)
+	must.SliceEmpty(t, conflicts)
+	must.Eq(t, []Thing{
+		{Name: "Rock", Health: intPtr(10), Flags: []Flag{"NO_UPDATE"}, Extent: Circle{R: 5}, Draw: StaticDraw{Img: ImageRef{Ind: 1}}},
+		{Name: "Bat", Speed: intPtr(5)},
+		{Name: "BigRock", Health: intPtr(100), Flags: []Flag{"NO_UPDATE", "SHADOW"}, Extent: Circle{R: 5}, Draw: StaticDraw{Img: ImageRef{Ind: 1}}},
+		{Name: "Gem", Class: []Class{"TREASURE"}},
+	}, d.Things)
+}
+
+func TestApplyPatchesConflicts(t *testing.T) {
+	d := testPatchData()
+	conflicts, err := d.ApplyPatches(
+		&Patch{Name: "a", Things: []ThingPatch{
+			{Name: "Rock", Set: map[string]any{"health": 20, "speed": 1}},
+			{Name: "Bat", Set: map[string]any{"speed": 3}},
+		}},
+		&Patch{Name: "b", Things: []ThingPatch{
+			{Name: "Rock", Set: map[string]any{"health": 30, "speed": 1}},
+			{Name: "Bat", Delete: true},
+		}},
+	)
+	must.NoError(t, err)
+	must.Eq(t, []PatchConflict{
+		{Thing: "Rock", Field: "health", Prev: "a", Patch: "b"},
+		{Thing: "Bat", Field: "speed", Prev: "a", Patch: "b"},
+	}, conflicts)
+	must.EqOp(t, 30, *d.ThingByName("Rock").Health)
+	must.Nil(t, d.ThingByName("Bat"))
+}
+
+func TestApplyPatchesErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		patch ThingPatch
+	}{
+		{"no name", ThingPatch{Set: map[string]any{"health": 1}}},
+		{"unknown field", ThingPatch{Name: "Rock", Set: map[string]any{"hp": 1}}},
+		{"rename", ThingPatch{Name: "Rock", Set: map[string]any{"name": "Stone"}}},
+		{"delete missing", ThingPatch{Name: "Stone", Delete: true}},
+		{"inherit missing", ThingPatch{Name: "Stone", Inherit: "Boulder"}},
+		{"inherit existing", ThingPatch{Name: "Bat", Inherit: "Rock"}},
+		{"bad value", ThingPatch{Name: "Rock", Set: map[string]any{"health": "lots"}}},
+		{"bad extent", ThingPatch{Name: "Rock", Set: map[string]any{"extent": map[string]any{"x": 1}}}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			d := testPatchData()
+			_, err := d.ApplyPatches(&Patch{Things: []ThingPatch{c.patch}})
+			must.Error(t, err)
+		})
+	}
+}
@@ -19,6 +19,12 @@ type Data struct {
 	Things []Thing `json:"things,omitempty"`
 	Spells []Spell `json:"spells,omitempty"`
 	Walls  []Wall  `json:"walls,omitempty"`
+	Floors []Floor `json:"floors,omitempty"`
+	Edges  []Edge  `json:"edges,omitempty"`
+	Audio  []Audio `json:"audio,omitempty"`
+
+	AudioEvents []AudioEvent `json:"audio_events,omitempty"`
+	Abilities   []Ability    `json:"abilities,omitempty"`
 }
 
 type Reader struct {
@@ -366,15 +372,17 @@ func (f *Reader) ReadAll() (*Data, error) {
 		}
 		switch sect {
 		case "FLOR":
-			// TODO
-			if err := f.skipFLOR(); err != nil {
+			fl, err := f.readFLOR()
+			if err != nil {
 				return &data, err
 			}
+			data.Floors = append(data.Floors, *fl)
 		case "EDGE":
-			// TODO
-			if err := f.skipEDGE(); err != nil {
+			e, err := f.readEDGE()
+			if err != nil {
 				return &data, err
 			}
+			data.Edges = append(data.Edges, *e)
 		case "WALL":
 			walls, err := f.readWALL()
 			if err != nil {
@@ -382,15 +390,17 @@ func (f *Reader) ReadAll() (*Data, error) {
 			}
 			data.Walls = append(data.Walls, walls...)
 		case "AUD ":
-			// TODO
-			if err := f.skipAUD(); err != nil {
+			list, err := f.readAUD()
+			if err != nil {
 				return &data, err
 			}
+			data.Audio = append(data.Audio, list...)
 		case "AVNT":
-			// TODO
-			if err := f.skipAVNT(); err != nil {
+			ev, err := f.readAVNT()
+			if err != nil {
 				return &data, err
 			}
+			data.AudioEvents = append(data.AudioEvents, *ev)
 		case "SPEL":
 			list, err := f.ReadSpellsSect()
 			if err != nil {
@@ -398,10 +408,11 @@ func (f *Reader) ReadAll() (*Data, error) {
 			}
 			data.Spells = list
 		case "ABIL":
-			// TODO
-			if err := f.skipABIL(); err != nil {
+			list, err := f.readABIL()
+			if err != nil {
 				return &data, err
 			}
+			data.Abilities = list
 		case "IMAG":
 			list, err := f.readImages()
 			if err != nil {
@@ -0,0 +1,61 @@
+package things
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/noxworld-dev/noxcrypt"
+	"github.com/shoenig/test/must"
+)
+
+// testFile is a helper for building decrypted thing.bin files in tests.
+type testFile struct {
+	bytes.Buffer
+}
+
+func (b *testFile) sect(name string) {
+	p := []byte(name)
+	swap4(p)
+	b.Write(p)
+}
+
+func (b *testFile) u8(v byte) {
+	b.WriteByte(v)
+}
+
+func (b *testFile) u16(v uint16) {
+	b.Write(binary.LittleEndian.AppendUint16(nil, v))
+}
+
+func (b *testFile) u32(v uint32) {
+	b.Write(binary.LittleEndian.AppendUint32(nil, v))
+}
+
+func (b *testFile) str8(s string) {
+	b.u8(byte(len(s)))
+	b.WriteString(s)
+}
+
+func (b *testFile) imgRef(ref ImageRef) {
+	if ref.Name == "" {
+		b.u32(uint32(ref.Ind))
+		return
+	}
+	b.u32(0xffffffff)
+	b.u8(byte(ref.Ind2))
+	b.str8(ref.Name)
+}
+
+func (b *testFile) open(t testing.TB) *Reader {
+	data := bytes.Clone(b.Bytes())
+	data = append(data, 0, 0, 0, 0) // padding section
+	if n := len(data) % 8; n != 0 {
+		data = append(data, make([]byte, 8-n)...)
+	}
+	err := crypt.Encode(data, crypt.ThingBin)
+	must.NoError(t, err)
+	r, err := OpenReader(bytes.NewReader(data), 0)
+	must.NoError(t, err)
+	return r
+}
@@ -512,3 +512,42 @@ func (f *Reader) ReadSpellsSect() ([]Spell, error) {
 	}
 	return out, nil // no END here
 }
+
+// WriteSpells writes spell definitions as SPEL section.
+func (f *Writer) WriteSpells(list []Spell) error {
+	if len(list) == 0 {
+		return f.err
+	}
+	f.writeSect("SPEL")
+	f.writeU32(uint32(len(list)))
+	for _, sp := range list {
+		if sp.ManaCost < 0 || sp.ManaCost > 0xff {
+			f.setErr(fmt.Errorf("spell %q: invalid mana cost: %d", sp.ID, sp.ManaCost))
+			return f.err
+		}
+		if sp.Price < 0 || sp.Price > 0xffff {
+			f.setErr(fmt.Errorf("spell %q: invalid price: %d", sp.ID, sp.Price))
+			return f.err
+		}
+		if len(sp.Phonemes) > 0xff {
+			f.setErr(fmt.Errorf("spell %q: too many phonemes", sp.ID))
+			return f.err
+		}
+		f.writeString8(sp.ID)
+		f.writeU8(byte(sp.ManaCost))
+		f.writeU16(uint16(sp.Price))
+		f.writeU8(byte(len(sp.Phonemes)))
+		for _, p := range sp.Phonemes {
+			f.writeU8(byte(p))
+		}
+		f.writeImageRef(sp.Icon)
+		f.writeImageRef(sp.IconEnabled)
+		f.writeU32(uint32(sp.Flags))
+		f.writeString8(string(sp.Title))
+		f.writeString16(string(sp.Desc))
+		f.writeStringNull(sp.CastSound)
+		f.writeStringNull(sp.OnSound)
+		f.writeStringNull(sp.OffSound)
+	}
+	return f.err
+}
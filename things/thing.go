@@ -2,6 +2,7 @@ package things
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"image"
 	"io"
@@ -176,6 +177,16 @@ func (f *Reader) ReadThings() ([]Thing, error) {
 	}
 }
 
+// ThingByName finds a thing definition by name. It returns nil if the thing is not defined.
+func (d *Data) ThingByName(name string) *Thing {
+	for i := range d.Things {
+		if d.Things[i].Name == name {
+			return &d.Things[i]
+		}
+	}
+	return nil
+}
+
 func parseThingAttrByKey(th *Thing, key, val string) (bool, error) {
 	f, ok := reflThingKeys[key]
 	if !ok {
@@ -536,3 +547,186 @@ func (f *Reader) skipThingPlayerDraw() error {
 		}
 	}
 }
+
+func unmarshalExtentJSON(data []byte) (Extent, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	switch {
+	case m == nil:
+		return nil, nil
+	case m["r"] != nil:
+		var v Circle
+		err := json.Unmarshal(data, &v)
+		return v, err
+	case m["w"] != nil || m["h"] != nil:
+		var v Box
+		err := json.Unmarshal(data, &v)
+		return v, err
+	case len(m) == 0:
+		return Center{}, nil
+	}
+	return nil, fmt.Errorf("unsupported extent: %s", data)
+}
+
+func (th *Thing) UnmarshalJSON(data []byte) error {
+	type thing Thing
+	var v struct {
+		*thing
+		Extent json.RawMessage `json:"extent,omitempty"`
+		Draw   json.RawMessage `json:"draw,omitempty"`
+	}
+	*th = Thing{}
+	v.thing = (*thing)(th)
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v.Extent) != 0 {
+		ext, err := unmarshalExtentJSON(v.Extent)
+		if err != nil {
+			return fmt.Errorf("thing %q: %w", th.Name, err)
+		}
+		th.Extent = ext
+	}
+	if len(v.Draw) != 0 {
+		dr, err := UnmarshalDrawJSON(v.Draw)
+		if err != nil {
+			return fmt.Errorf("thing %q: %w", th.Name, err)
+		}
+		th.Draw = dr
+	}
+	return nil
+}
+
+func formatFloat(v float64, bits int) string {
+	return strconv.FormatFloat(v, 'f', -1, bits)
+}
+
+func formatExtent(ext Extent) (string, error) {
+	switch ext := ext.(type) {
+	case Center:
+		return "CENTER", nil
+	case Circle:
+		return "CIRCLE " + formatFloat(float64(ext.R), 32), nil
+	case Box:
+		return "BOX " + formatFloat(float64(ext.W), 32) + " " + formatFloat(float64(ext.H), 32), nil
+	}
+	return "", fmt.Errorf("unsupported extent type: %T", ext)
+}
+
+// formatThingAttr formats a simple thing attribute value. It is the inverse of parseThingAttrByKey.
+// It returns false if the value is not set and must be omitted.
+func formatThingAttr(rv reflect.Value) (string, bool, error) {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", false, nil
+		}
+		rv = rv.Elem()
+	} else if rv.IsZero() {
+		return "", false, nil
+	}
+	if rv.Type() == reflProcFunc {
+		fnc := rv.Interface().(ProcFunc)
+		return strings.Join(append([]string{fnc.Name}, fnc.Args...), " "), true, nil
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true, nil
+	case reflect.Int:
+		return strconv.FormatInt(rv.Int(), 10), true, nil
+	case reflect.Float64:
+		return formatFloat(rv.Float(), 64), true, nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.String {
+			arr := make([]string, 0, rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				arr = append(arr, rv.Index(i).String())
+			}
+			return strings.Join(arr, "+"), true, nil
+		}
+	}
+	return "", false, fmt.Errorf("unsupported Go type: %v", rv.Type())
+}
+
+// WriteThings writes each thing definition as a separate THNG section.
+func (f *Writer) WriteThings(list []Thing) error {
+	for i := range list {
+		f.writeTHNG(&list[i])
+	}
+	return f.err
+}
+
+func (f *Writer) writeThingAttr(key, val string) {
+	if val == "" {
+		f.writeString8(key)
+	} else {
+		f.writeString8(key + " = " + val)
+	}
+}
+
+func (f *Writer) writeTHNG(th *Thing) {
+	f.writeSect("THNG")
+	f.writeString8(th.Name)
+	rth := reflect.ValueOf(th).Elem()
+	for i := 0; i < reflThing.NumField(); i++ {
+		field := reflThing.Field(i)
+		switch field.Name {
+		case "Menu":
+			if th.Menu != nil {
+				f.writeThingAttr("MENUICON", "")
+				f.writeImageRef(th.Menu)
+			}
+			continue
+		case "Image":
+			if th.Image != nil {
+				f.writeThingAttr("PRETTYIMAGE", "")
+				f.writeImageRef(th.Image)
+			}
+			continue
+		case "Size":
+			if th.Size != nil {
+				f.writeThingAttr("SIZE", fmt.Sprintf("%d %d", th.Size.X, th.Size.Y))
+			}
+			continue
+		case "ZSize":
+			if th.ZSize != nil {
+				f.writeThingAttr("ZSIZE", fmt.Sprintf("%d %d", th.ZSize.Bottom, th.ZSize.Top))
+			}
+			continue
+		case "Extent":
+			if th.Extent != nil {
+				val, err := formatExtent(th.Extent)
+				if err != nil {
+					f.setErr(fmt.Errorf("thing %q: %w", th.Name, err))
+					return
+				}
+				f.writeThingAttr("EXTENT", val)
+			}
+			continue
+		case "LightColor":
+			if c := th.LightColor; c != nil {
+				f.writeThingAttr("LIGHTCOLOR", fmt.Sprintf("%d %d %d", c.R, c.G, c.B))
+			}
+			continue
+		case "Draw":
+			if th.Draw != nil {
+				f.writeThingAttr("DRAW", "")
+				f.writeThingDraw(th.Draw)
+			}
+			continue
+		}
+		key := strings.SplitN(field.Tag.Get("nox"), ",", 2)[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		val, ok, err := formatThingAttr(rth.Field(i))
+		if err != nil {
+			f.setErr(fmt.Errorf("thing %q: cannot format %q: %w", th.Name, key, err))
+			return
+		} else if ok {
+			f.writeThingAttr(key, val)
+		}
+	}
+	f.writeU8(0)
+}
@@ -1,11 +1,18 @@
 package things
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strconv"
 	"strings"
 
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
 	"github.com/noxworld-dev/opennox-lib/player"
 )
 
@@ -13,6 +20,13 @@ type Draw interface {
 	isDraw()
 }
 
+// DirectionalDraw is implemented by draws that contain one image for each direction.
+type DirectionalDraw interface {
+	Draw
+	// Frames returns images for each direction.
+	Frames() []ImageRef
+}
+
 type BaseDraw struct {
 	Img ImageRef `json:"base"`
 }
@@ -97,6 +111,85 @@ type ConditionalAnimateDraw struct {
 
 func (ConditionalAnimateDraw) isDraw() {}
 
+// StateAnimation is an animation used by AnimateStateDraw for a specific object state.
+type StateAnimation struct {
+	State uint32    `json:"state"`
+	Name  string    `json:"name,omitempty"`
+	Sound string    `json:"sound,omitempty"`
+	Anim  Animation `json:"anim"`
+}
+
+type AnimateStateDraw struct {
+	Anims []StateAnimation `json:"state_anim"`
+}
+
+func (AnimateStateDraw) isDraw() {}
+
+// VectorAnimation is an animation with separate frames for each of 8 directions.
+type VectorAnimation struct {
+	FramesPerDir byte          `json:"frames_per_dir"`
+	Field        byte          `json:"field,omitempty"`
+	Kind         AnimationKind `json:"kind"`
+	Frames       [8][]ImageRef `json:"frames"`
+}
+
+type VectorAnimateDraw struct {
+	Anim VectorAnimation `json:"vector_anim"`
+}
+
+func (VectorAnimateDraw) isDraw() {}
+
+type ReleasedSoulDraw struct {
+	Anim VectorAnimation `json:"released_soul"`
+}
+
+func (ReleasedSoulDraw) isDraw() {}
+
+// SlaveDraw contains one image for each direction.
+type SlaveDraw struct {
+	Imgs []ImageRef `json:"slave"`
+}
+
+func (SlaveDraw) isDraw() {}
+
+func (d SlaveDraw) Frames() []ImageRef { return d.Imgs }
+
+// BoulderDraw contains one image for each direction.
+type BoulderDraw struct {
+	Imgs []ImageRef `json:"boulder"`
+}
+
+func (BoulderDraw) isDraw() {}
+
+func (d BoulderDraw) Frames() []ImageRef { return d.Imgs }
+
+// ArrowDraw contains one image for each direction.
+type ArrowDraw struct {
+	Imgs []ImageRef `json:"arrow"`
+}
+
+func (ArrowDraw) isDraw() {}
+
+func (d ArrowDraw) Frames() []ImageRef { return d.Imgs }
+
+// WeakArrowDraw contains one image for each direction.
+type WeakArrowDraw struct {
+	Imgs []ImageRef `json:"weak_arrow"`
+}
+
+func (WeakArrowDraw) isDraw() {}
+
+func (d WeakArrowDraw) Frames() []ImageRef { return d.Imgs }
+
+// HarpoonDraw contains one image for each direction.
+type HarpoonDraw struct {
+	Imgs []ImageRef `json:"harpoon"`
+}
+
+func (HarpoonDraw) isDraw() {}
+
+func (d HarpoonDraw) Frames() []ImageRef { return d.Imgs }
+
 type MonsterAnimationType byte
 
 func (v MonsterAnimationType) String() string {
@@ -185,8 +278,10 @@ type PlayerDraw struct {
 
 func (PlayerDraw) isDraw() {}
 
+// UnknownDraw is a draw type that is not decoded yet. It preserves raw draw data.
 type UnknownDraw struct {
 	Type string `json:"unknown"`
+	Data []byte `json:"data,omitempty"`
 }
 
 func (UnknownDraw) isDraw() {}
@@ -267,6 +362,87 @@ func (f *Reader) skipAnimations8() error {
 	return nil
 }
 
+func (f *Reader) readDirFrames(n int) ([8][]ImageRef, error) {
+	var dirs [8][]ImageRef
+	for i := range dirs {
+		frames := make([]ImageRef, 0, n)
+		for j := 0; j < n; j++ {
+			ref, err := f.readImageRef()
+			if err != nil {
+				return dirs, err
+			}
+			frames = append(frames, *ref)
+		}
+		dirs[i] = frames
+	}
+	return dirs, nil
+}
+
+func (f *Reader) readVectorAnimation() (*VectorAnimation, error) {
+	n, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	fld, err := f.readU8()
+	if err != nil {
+		return nil, err
+	}
+	kind, err := f.readBytes8()
+	if err != nil {
+		return nil, err
+	}
+	ani := &VectorAnimation{FramesPerDir: n, Field: fld}
+	if err := ani.Kind.UnmarshalText(kind); err != nil {
+		return nil, err
+	}
+	ani.Frames, err = f.readDirFrames(int(n))
+	if err != nil {
+		return nil, err
+	}
+	return ani, nil
+}
+
+func (f *Reader) readAnimateStateDraw() (AnimateStateDraw, error) {
+	d := AnimateStateDraw{}
+	for {
+		sect, err := f.readSect()
+		if err == io.EOF {
+			return d, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return d, err
+		}
+		switch sect {
+		default:
+			return d, fmt.Errorf("unsupported state draw sect: %q", sect)
+		case "END ":
+			return d, nil
+		case "STAT":
+			state, err := f.readU32()
+			if err != nil {
+				return d, err
+			}
+			name, err := f.readString8()
+			if err != nil {
+				return d, err
+			}
+			snd, err := f.readString8()
+			if err != nil {
+				return d, err
+			}
+			ani, err := f.readAnimation()
+			if err != nil {
+				return d, err
+			}
+			d.Anims = append(d.Anims, StateAnimation{
+				State: state,
+				Name:  name,
+				Sound: snd,
+				Anim:  *ani,
+			})
+		}
+	}
+}
+
 func (f *Reader) readMonsterDraw() (MonsterDraw, error) {
 	d := MonsterDraw{}
 	for {
@@ -316,16 +492,9 @@ func (f *Reader) readMonsterDraw() (MonsterDraw, error) {
 			if err = ani.Kind.UnmarshalText(kind); err != nil {
 				return d, err
 			}
-			for i := 0; i < 8; i++ {
-				frames := make([]ImageRef, 0, int(framesN))
-				for j := 0; j < int(framesN); j++ {
-					ref, err := f.readImageRef()
-					if err != nil {
-						return d, err
-					}
-					frames = append(frames, *ref)
-				}
-				ani.Frames[i] = frames
+			ani.Frames, err = f.readDirFrames(int(framesN))
+			if err != nil {
+				return d, err
 			}
 			d.Anims = append(d.Anims, ani)
 		}
@@ -378,18 +547,12 @@ func (f *Reader) readPlayerDraw() (PlayerDraw, error) {
 			if err != nil {
 				return d, err
 			}
-			framesN := int(lastAni.FramesPerDir)
-			var dirs [8][]ImageRef
-			for i := 0; i < 8; i++ {
-				frames := make([]ImageRef, 0, framesN)
-				for j := 0; j < framesN; j++ {
-					ref, err := f.readImageRef()
-					if err != nil {
-						return d, err
-					}
-					frames = append(frames, *ref)
-				}
-				dirs[i] = frames
+			if lastAni == nil {
+				return d, errors.New("player draw sequence without a state")
+			}
+			dirs, err := f.readDirFrames(int(lastAni.FramesPerDir))
+			if err != nil {
+				return d, err
 			}
 			if lastAni.Parts == nil {
 				lastAni.Parts = make(map[player.AnimPart][8][]ImageRef)
@@ -525,16 +688,6 @@ func (f *Reader) readThingDraw() (Draw, error) {
 		case "MonsterGeneratorDraw":
 			return MonsterGeneratorDraw{Anims: anis}, nil
 		}
-	case "AnimateStateDraw":
-		if err := f.skipThingAnimStateDraw(); err != nil {
-			return nil, err // FIXME
-		}
-		return UnknownDraw{Type: dname}, nil
-	case "VectorAnimateDraw", "ReleasedSoulDraw":
-		if err := f.skipThingAnimVectorDraw(); err != nil {
-			return nil, err // FIXME
-		}
-		return UnknownDraw{Type: dname}, nil
 	case "MonsterDraw", "MaidenDraw":
 		d, err := f.readMonsterDraw()
 		if err != nil {
@@ -552,14 +705,426 @@ func (f *Reader) readThingDraw() (Draw, error) {
 			return nil, err
 		}
 		return d, nil
-	case "SlaveDraw", "BoulderDraw", "ArrowDraw", "WeakArrowDraw", "HarpoonDraw":
-		if err := f.skipImageRefs8(); err != nil {
-			return nil, err // FIXME
+	case "AnimateStateDraw":
+		d, err := f.readAnimateStateDraw()
+		if err != nil {
+			return nil, err
 		}
-	default:
-		if err := f.skip(int(sectSz)); err != nil {
+		return d, nil
+	case "VectorAnimateDraw", "ReleasedSoulDraw":
+		anim, err := f.readVectorAnimation()
+		if err != nil {
 			return nil, err
 		}
+		switch dname {
+		case "VectorAnimateDraw":
+			return VectorAnimateDraw{Anim: *anim}, nil
+		case "ReleasedSoulDraw":
+			return ReleasedSoulDraw{Anim: *anim}, nil
+		}
+	case "SlaveDraw", "BoulderDraw", "ArrowDraw", "WeakArrowDraw", "HarpoonDraw":
+		imgs, err := f.readImageRefs8()
+		if err != nil {
+			return nil, err
+		}
+		switch dname {
+		case "SlaveDraw":
+			return SlaveDraw{Imgs: imgs}, nil
+		case "BoulderDraw":
+			return BoulderDraw{Imgs: imgs}, nil
+		case "ArrowDraw":
+			return ArrowDraw{Imgs: imgs}, nil
+		case "WeakArrowDraw":
+			return WeakArrowDraw{Imgs: imgs}, nil
+		case "HarpoonDraw":
+			return HarpoonDraw{Imgs: imgs}, nil
+		}
+	}
+	// draw types not known to the engine, preserve them as-is
+	data := make([]byte, sectSz)
+	if _, err := f.read(data); err != nil {
+		return nil, err
+	}
+	return UnknownDraw{Type: dname, Data: data}, nil
+}
+
+// DrawImages returns all image references used by the Draw, in the order they are defined.
+func DrawImages(d Draw) []ImageRef {
+	var out []ImageRef
+	addDirs := func(dirs *[8][]ImageRef) {
+		for _, frames := range dirs {
+			out = append(out, frames...)
+		}
+	}
+	switch d := d.(type) {
+	case BaseDraw:
+		out = append(out, d.Img)
+	case StaticDraw:
+		out = append(out, d.Img)
+	case WeaponDraw:
+		out = append(out, d.Img)
+	case ArmorDraw:
+		out = append(out, d.Img)
+	case StaticRandomDraw:
+		out = append(out, d.Imgs...)
+	case DoorDraw:
+		out = append(out, d.Imgs...)
+	case SlaveDraw:
+		out = append(out, d.Imgs...)
+	case BoulderDraw:
+		out = append(out, d.Imgs...)
+	case ArrowDraw:
+		out = append(out, d.Imgs...)
+	case WeakArrowDraw:
+		out = append(out, d.Imgs...)
+	case HarpoonDraw:
+		out = append(out, d.Imgs...)
+	case AnimateDraw:
+		out = append(out, d.Anim.Frames...)
+	case GlyphDraw:
+		out = append(out, d.Anim.Frames...)
+	case WeaponAnimateDraw:
+		out = append(out, d.Anim.Frames...)
+	case ArmorAnimateDraw:
+		out = append(out, d.Anim.Frames...)
+	case FlagDraw:
+		out = append(out, d.Anim.Frames...)
+	case SphericalShieldDraw:
+		out = append(out, d.Anim.Frames...)
+	case SummonEffectDraw:
+		out = append(out, d.Anim.Frames...)
+	case ConditionalAnimateDraw:
+		for _, a := range d.Anims {
+			out = append(out, a.Frames...)
+		}
+	case MonsterGeneratorDraw:
+		for _, a := range d.Anims {
+			out = append(out, a.Frames...)
+		}
+	case AnimateStateDraw:
+		for _, a := range d.Anims {
+			out = append(out, a.Anim.Frames...)
+		}
+	case VectorAnimateDraw:
+		addDirs(&d.Anim.Frames)
+	case ReleasedSoulDraw:
+		addDirs(&d.Anim.Frames)
+	case MonsterDraw:
+		for _, a := range d.Anims {
+			addDirs(&a.Frames)
+		}
+	case MaidenDraw:
+		for _, a := range d.Anims {
+			addDirs(&a.Frames)
+		}
+	case PlayerDraw:
+		types := maps.Keys(d.Anims)
+		slices.Sort(types)
+		for _, typ := range types {
+			a := d.Anims[typ]
+			parts := maps.Keys(a.Parts)
+			slices.Sort(parts)
+			for _, part := range parts {
+				dirs := a.Parts[part]
+				addDirs(&dirs)
+			}
+		}
+	}
+	return out
+}
+
+// UnmarshalDrawJSON decodes Draw from JSON. Draw type is detected by the JSON key used by each type.
+func UnmarshalDrawJSON(data []byte) (Draw, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	} else if m == nil {
+		return nil, nil
+	}
+	var d Draw
+	for key := range m {
+		var v Draw
+		switch key {
+		case "base":
+			v = &BaseDraw{}
+		case "static":
+			v = &StaticDraw{}
+		case "weapon":
+			v = &WeaponDraw{}
+		case "armor":
+			v = &ArmorDraw{}
+		case "random":
+			v = &StaticRandomDraw{}
+		case "door":
+			v = &DoorDraw{}
+		case "anim":
+			v = &AnimateDraw{}
+		case "glyph":
+			v = &GlyphDraw{}
+		case "weapon_anim":
+			v = &WeaponAnimateDraw{}
+		case "armor_anim":
+			v = &ArmorAnimateDraw{}
+		case "flag":
+			v = &FlagDraw{}
+		case "spherical_shield":
+			v = &SphericalShieldDraw{}
+		case "summon":
+			v = &SummonEffectDraw{}
+		case "cond_anim":
+			v = &ConditionalAnimateDraw{}
+		case "monster":
+			v = &MonsterDraw{}
+		case "maiden":
+			v = &MaidenDraw{}
+		case "monster_gen":
+			v = &MonsterGeneratorDraw{}
+		case "player":
+			v = &PlayerDraw{}
+		case "state_anim":
+			v = &AnimateStateDraw{}
+		case "vector_anim":
+			v = &VectorAnimateDraw{}
+		case "released_soul":
+			v = &ReleasedSoulDraw{}
+		case "slave":
+			v = &SlaveDraw{}
+		case "boulder":
+			v = &BoulderDraw{}
+		case "arrow":
+			v = &ArrowDraw{}
+		case "weak_arrow":
+			v = &WeakArrowDraw{}
+		case "harpoon":
+			v = &HarpoonDraw{}
+		case "unknown":
+			v = &UnknownDraw{}
+		default:
+			continue
+		}
+		if d != nil {
+			return nil, errors.New("ambiguous draw type")
+		}
+		d = v
+	}
+	if d == nil {
+		return nil, fmt.Errorf("unsupported draw: %s", data)
+	}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, err
+	}
+	// dereference the pointer, all draw types are stored by value
+	return reflect.ValueOf(d).Elem().Interface().(Draw), nil
+}
+
+func (f *Writer) writeAnimation(ani *Animation) {
+	if len(ani.Frames) > 0xff {
+		f.setErr(fmt.Errorf("too many animation frames: %d", len(ani.Frames)))
+		return
+	}
+	f.writeU8(byte(len(ani.Frames)))
+	f.writeU8(ani.Field)
+	kind, err := ani.Kind.MarshalText()
+	if err != nil {
+		f.setErr(err)
+		return
+	}
+	f.writeBytes8(kind)
+	for _, fr := range ani.Frames {
+		f.writeImageRef(&fr)
+	}
+}
+
+func (f *Writer) writeAnimations8(list []Animation) {
+	if len(list) > 0xff {
+		f.setErr(fmt.Errorf("too many animations: %d", len(list)))
+		return
+	}
+	f.writeU8(byte(len(list)))
+	for i := range list {
+		f.writeAnimation(&list[i])
+	}
+}
+
+func (f *Writer) writeDirFrames(dirs *[8][]ImageRef, n int) {
+	for _, frames := range dirs {
+		if len(frames) != n {
+			f.setErr(fmt.Errorf("expected %d frames per direction, got %d", n, len(frames)))
+			return
+		}
+		for _, fr := range frames {
+			f.writeImageRef(&fr)
+		}
+	}
+}
+
+func (f *Writer) writeVectorAnimation(ani *VectorAnimation) {
+	f.writeU8(ani.FramesPerDir)
+	f.writeU8(ani.Field)
+	kind, err := ani.Kind.MarshalText()
+	if err != nil {
+		f.setErr(err)
+		return
+	}
+	f.writeBytes8(kind)
+	f.writeDirFrames(&ani.Frames, int(ani.FramesPerDir))
+}
+
+func (f *Writer) writeAnimateStateDraw(d AnimateStateDraw) {
+	for _, ani := range d.Anims {
+		f.writeSect("STAT")
+		f.writeU32(ani.State)
+		f.writeString8(ani.Name)
+		f.writeString8(ani.Sound)
+		f.writeAnimation(&ani.Anim)
+	}
+	f.writeSect("END ")
+}
+
+func (f *Writer) writeMonsterDraw(d MonsterDraw) {
+	for _, ani := range d.Anims {
+		f.writeSect("STAT")
+		f.writeU8(byte(ani.Type))
+		f.writeString8(ani.Sound)
+		f.writeString8(ani.Field8)
+		f.writeU8(ani.FramesPerDir)
+		f.writeU8(ani.Field10)
+		kind, err := ani.Kind.MarshalText()
+		if err != nil {
+			f.setErr(err)
+			return
+		}
+		f.writeBytes8(kind)
+		f.writeDirFrames(&ani.Frames, int(ani.FramesPerDir))
+	}
+	f.writeSect("END ")
+}
+
+func (f *Writer) writePlayerDraw(d PlayerDraw) {
+	types := maps.Keys(d.Anims)
+	slices.Sort(types)
+	for _, typ := range types {
+		ani := d.Anims[typ]
+		f.writeSect("STAT")
+		f.writeString8(string(typ))
+		f.writeU8(ani.FramesPerDir)
+		f.writeU8(ani.Field8)
+		f.writeString8(ani.Field12)
+		parts := maps.Keys(ani.Parts)
+		slices.Sort(parts)
+		for _, part := range parts {
+			dirs := ani.Parts[part]
+			f.writeSect("SEQU")
+			f.writeString8(string(part))
+			f.writeDirFrames(&dirs, int(ani.FramesPerDir))
+		}
+	}
+	f.writeSect("END ")
+}
+
+// encodeThingDraw returns draw type name and encoded draw data.
+func encodeThingDraw(d Draw) (string, []byte, error) {
+	var buf bytes.Buffer
+	f := NewWriter(&buf)
+	var name string
+	switch d := d.(type) {
+	default:
+		return "", nil, fmt.Errorf("unsupported draw type: %T", d)
+	case BaseDraw:
+		name = "BaseDraw"
+		f.writeImageRef(&d.Img)
+	case StaticDraw:
+		name = "StaticDraw"
+		f.writeImageRef(&d.Img)
+	case WeaponDraw:
+		name = "WeaponDraw"
+		f.writeImageRef(&d.Img)
+	case ArmorDraw:
+		name = "ArmorDraw"
+		f.writeImageRef(&d.Img)
+	case StaticRandomDraw:
+		name = "StaticRandomDraw"
+		f.writeImageRefs8(d.Imgs)
+	case DoorDraw:
+		name = "DoorDraw"
+		f.writeImageRefs8(d.Imgs)
+	case AnimateDraw:
+		name = "AnimateDraw"
+		f.writeAnimation(&d.Anim)
+	case GlyphDraw:
+		name = "GlyphDraw"
+		f.writeAnimation(&d.Anim)
+	case WeaponAnimateDraw:
+		name = "WeaponAnimateDraw"
+		f.writeAnimation(&d.Anim)
+	case ArmorAnimateDraw:
+		name = "ArmorAnimateDraw"
+		f.writeAnimation(&d.Anim)
+	case FlagDraw:
+		name = "FlagDraw"
+		f.writeAnimation(&d.Anim)
+	case SphericalShieldDraw:
+		name = "SphericalShieldDraw"
+		f.writeAnimation(&d.Anim)
+	case SummonEffectDraw:
+		name = "SummonEffectDraw"
+		f.writeAnimation(&d.Anim)
+	case ConditionalAnimateDraw:
+		name = "ConditionalAnimateDraw"
+		f.writeAnimations8(d.Anims)
+	case MonsterGeneratorDraw:
+		name = "MonsterGeneratorDraw"
+		f.writeAnimations8(d.Anims)
+	case MonsterDraw:
+		name = "MonsterDraw"
+		f.writeMonsterDraw(d)
+	case MaidenDraw:
+		name = "MaidenDraw"
+		f.writeMonsterDraw(MonsterDraw(d))
+	case PlayerDraw:
+		name = "PlayerDraw"
+		f.writePlayerDraw(d)
+	case AnimateStateDraw:
+		name = "AnimateStateDraw"
+		f.writeAnimateStateDraw(d)
+	case VectorAnimateDraw:
+		name = "VectorAnimateDraw"
+		f.writeVectorAnimation(&d.Anim)
+	case ReleasedSoulDraw:
+		name = "ReleasedSoulDraw"
+		f.writeVectorAnimation(&d.Anim)
+	case SlaveDraw:
+		name = "SlaveDraw"
+		f.writeImageRefs8(d.Imgs)
+	case BoulderDraw:
+		name = "BoulderDraw"
+		f.writeImageRefs8(d.Imgs)
+	case ArrowDraw:
+		name = "ArrowDraw"
+		f.writeImageRefs8(d.Imgs)
+	case WeakArrowDraw:
+		name = "WeakArrowDraw"
+		f.writeImageRefs8(d.Imgs)
+	case HarpoonDraw:
+		name = "HarpoonDraw"
+		f.writeImageRefs8(d.Imgs)
+	case UnknownDraw:
+		name = d.Type
+		f.write(d.Data)
+	}
+	if err := f.Flush(); err != nil {
+		return "", nil, err
+	}
+	return name, buf.Bytes(), nil
+}
+
+func (f *Writer) writeThingDraw(d Draw) {
+	name, data, err := encodeThingDraw(d)
+	if err != nil {
+		f.setErr(err)
+		return
 	}
-	return UnknownDraw{Type: dname}, nil
+	f.writeString8(name)
+	f.writeU64align(uint64(len(data)))
+	f.write(data)
 }
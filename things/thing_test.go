@@ -10,3 +10,35 @@ func TestThingFixAttrs(t *testing.T) {
 	arr := fixThingAttrs("MASS = 6  DESTROY = DefaultDestroy")
 	must.Eq(t, []string{"MASS = 6", "DESTROY = DefaultDestroy"}, arr)
 }
+
+func TestDrawImages(t *testing.T) {
+	d := MonsterDraw{Anims: []MonsterAnimation{{
+		FramesPerDir: 1,
+		Frames:       [8][]ImageRef{{{Ind: 1}}, {{Ind: 2}}, {{Ind: 3}}, {{Ind: 4}}, {{Ind: 5}}, {{Ind: 6}}, {{Ind: 7}}, {{Ind: 8}}},
+	}}}
+	must.Eq(t, []ImageRef{{Ind: 1}, {Ind: 2}, {Ind: 3}, {Ind: 4}, {Ind: 5}, {Ind: 6}, {Ind: 7}, {Ind: 8}}, DrawImages(d))
+	must.Eq(t, []ImageRef{{Ind: 3}, {Name: "Gem"}}, DrawImages(StaticRandomDraw{Imgs: []ImageRef{{Ind: 3}, {Name: "Gem"}}}))
+	must.SliceEmpty(t, DrawImages(nil))
+}
+
+func TestImageIndex(t *testing.T) {
+	d := &Data{Images: []Image{
+		{Name: "Gem", Img: &ImageRef{Ind: 10}},
+		{Name: "Fire", Ani: &Animation{Frames: []ImageRef{{Ind: 20}, {Ind: 21}}}},
+	}}
+	for _, c := range []struct {
+		ref ImageRef
+		ind int
+		ok  bool
+	}{
+		{ImageRef{Ind: 5}, 5, true},
+		{ImageRef{Name: "Gem"}, 10, true},
+		{ImageRef{Name: "Fire", Ind2: 1}, 21, true},
+		{ImageRef{Name: "Fire", Ind2: 2}, 0, false},
+		{ImageRef{Name: "Missing"}, 0, false},
+	} {
+		ind, ok := d.ImageIndex(c.ref)
+		must.EqOp(t, c.ok, ok)
+		must.EqOp(t, c.ind, ind)
+	}
+}
@@ -11,9 +11,11 @@ type Wall struct {
 	Unk2       uint32
 	Unk3       uint32
 	Unk4       uint16
+	Debris     []string          `json:"debris,omitempty"`
 	OpenSound  string            `json:"open_sound,omitempty"`
 	CloseSound string            `json:"close_sound,omitempty"`
 	BreakSound string            `json:"break_sound,omitempty"`
+	Variations byte              `json:"variations,omitempty"` // variations count hint
 	Directions [15]WallDirection `json:"directions,omitempty"`
 }
 
@@ -147,7 +149,7 @@ func (f *Reader) readWALL() ([]Wall, error) {
 		if err != nil {
 			return nil, err
 		}
-		_, err = f.readU8() // variations count hint
+		vars, err := f.readU8() // variations count hint
 		if err != nil {
 			return nil, err
 		}
@@ -157,9 +159,11 @@ func (f *Reader) readWALL() ([]Wall, error) {
 			Unk2:       unk2,
 			Unk3:       unk3,
 			Unk4:       unk4,
+			Debris:     debris,
 			OpenSound:  sopen,
 			CloseSound: sclose,
 			BreakSound: sbreak,
+			Variations: vars,
 		}
 		for i := 0; i < 15; i++ {
 			vn, err := f.readU64align()
@@ -195,3 +199,39 @@ func (f *Reader) readWALL() ([]Wall, error) {
 	}
 	return out, f.checkEND()
 }
+
+// WriteWalls writes wall definitions as WALL section.
+func (f *Writer) WriteWalls(list []Wall) error {
+	if len(list) == 0 {
+		return f.err
+	}
+	f.writeSect("WALL")
+	f.writeU32(uint32(len(list)))
+	for _, w := range list {
+		f.writeString8(w.Name)
+		f.writeU32(w.Unk1)
+		f.writeU32(w.Unk2)
+		f.writeU32(w.Unk3)
+		f.writeU16(w.Unk4)
+		f.writeU64align(uint64(len(w.Debris)))
+		for _, s := range w.Debris {
+			f.writeString8(s)
+		}
+		f.writeString8(w.OpenSound)
+		f.writeString8(w.CloseSound)
+		f.writeString8(w.BreakSound)
+		f.writeU8(w.Variations)
+		for _, d := range w.Directions {
+			f.writeU64align(uint64(len(d.Variants)))
+			for _, v := range d.Variants {
+				for _, img := range v.Images {
+					f.writeI32(int32(img.Pt.X))
+					f.writeI32(int32(img.Pt.Y))
+					f.writeImageRef(&img.Img)
+				}
+			}
+		}
+	}
+	f.writeSect("END ")
+	return f.err
+}
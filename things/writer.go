@@ -0,0 +1,210 @@
+package things
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/noxworld-dev/noxcrypt"
+
+	"github.com/noxworld-dev/opennox-lib/ifs"
+)
+
+type Writer struct {
+	w   *bufio.Writer
+	cw  *crypt.Writer
+	c   io.Closer
+	off int64
+	err error
+}
+
+// NewWriter creates a new thing.bin file writer. It writes the file without encryption.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// CreateWriter creates a new thing.bin file writer and encrypts it with a given key.
+// If key is set to zero, default key is used.
+func CreateWriter(w io.Writer, key int) (*Writer, error) {
+	if key == 0 {
+		key = crypt.ThingBin
+	}
+	cw, err := crypt.NewWriter(w, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{w: bufio.NewWriter(cw), cw: cw}, nil
+}
+
+// Create thing.bin file. It is similar to CreateWriter, but will automatically create file and select encryption key.
+func Create(path string) (*Writer, error) {
+	key, ok := crypt.KeyForFile(path)
+	if !ok {
+		return nil, errors.New("unsupported things file")
+	}
+	f, err := ifs.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	tw, err := CreateWriter(f, key)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	tw.c = f
+	return tw, nil
+}
+
+// Flush writes buffered data to the underlying writer.
+func (f *Writer) Flush() error {
+	if f.err != nil {
+		return f.err
+	}
+	if err := f.w.Flush(); err != nil {
+		f.err = err
+		return err
+	}
+	return nil
+}
+
+// Close writes the trailing padding, flushes the data and closes the underlying file, if any.
+func (f *Writer) Close() error {
+	f.write([]byte{0, 0, 0, 0}) // padding
+	err := f.Flush()
+	if err == nil && f.cw != nil {
+		err = f.cw.Close()
+	}
+	if f.c != nil {
+		if err2 := f.c.Close(); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+func (f *Writer) setErr(err error) {
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+func (f *Writer) write(p []byte) {
+	if f.err != nil {
+		return
+	}
+	n, err := f.w.Write(p)
+	f.off += int64(n)
+	f.setErr(err)
+}
+
+func (f *Writer) writeSect(name string) {
+	var sect [4]byte
+	copy(sect[:], name)
+	swap4(sect[:])
+	f.write(sect[:])
+}
+
+func (f *Writer) writeU8(v byte) {
+	f.write([]byte{v})
+}
+
+func (f *Writer) writeU16(v uint16) {
+	var b [2]byte
+	endiness.PutUint16(b[:], v)
+	f.write(b[:])
+}
+
+func (f *Writer) writeU32(v uint32) {
+	var b [4]byte
+	endiness.PutUint32(b[:], v)
+	f.write(b[:])
+}
+
+func (f *Writer) writeI32(v int32) {
+	f.writeU32(uint32(v))
+}
+
+func (f *Writer) writeU64align(v uint64) {
+	if over := int(f.off % 8); over != 0 {
+		f.write(make([]byte, 8-over))
+	}
+	var b [8]byte
+	endiness.PutUint64(b[:], v)
+	f.write(b[:])
+}
+
+func (f *Writer) writeBytes8(p []byte) {
+	if len(p) > 0xff {
+		f.setErr(fmt.Errorf("string is too long: %q", p))
+		return
+	}
+	f.writeU8(byte(len(p)))
+	f.write(p)
+}
+
+func (f *Writer) writeBytes16(p []byte) {
+	if len(p) > 0xffff {
+		f.setErr(fmt.Errorf("string is too long: [%d]", len(p)))
+		return
+	}
+	f.writeU16(uint16(len(p)))
+	f.write(p)
+}
+
+func (f *Writer) writeString8(s string) {
+	f.writeBytes8([]byte(s))
+}
+
+func (f *Writer) writeString16(s string) {
+	f.writeBytes16([]byte(s))
+}
+
+// writeStrings8 writes a list of strings terminated by an empty string.
+func (f *Writer) writeStrings8(list []string) {
+	for _, s := range list {
+		if s == "" {
+			f.setErr(errors.New("empty string in the list"))
+			return
+		}
+		f.writeString8(s)
+	}
+	f.writeU8(0)
+}
+
+// writeStringNull writes a string, replacing empty value with "NULL".
+func (f *Writer) writeStringNull(s string) {
+	if s == "" {
+		s = "NULL"
+	}
+	f.writeString8(s)
+}
+
+// WriteAll writes all sections from Data.
+func (f *Writer) WriteAll(d *Data) error {
+	if err := f.WriteFloors(d.Floors); err != nil {
+		return err
+	}
+	if err := f.WriteEdges(d.Edges); err != nil {
+		return err
+	}
+	if err := f.WriteWalls(d.Walls); err != nil {
+		return err
+	}
+	if err := f.WriteAudio(d.Audio); err != nil {
+		return err
+	}
+	if err := f.WriteAudioEvents(d.AudioEvents); err != nil {
+		return err
+	}
+	if err := f.WriteSpells(d.Spells); err != nil {
+		return err
+	}
+	if err := f.WriteAbilities(d.Abilities); err != nil {
+		return err
+	}
+	if err := f.WriteImages(d.Images); err != nil {
+		return err
+	}
+	return f.WriteThings(d.Things)
+}
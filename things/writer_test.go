@@ -0,0 +1,184 @@
+package things
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/player"
+	"github.com/noxworld-dev/opennox-lib/spell"
+)
+
+func intPtr(v int) *int { return &v }
+
+var testWriterData = &Data{
+	Floors: []Floor{{
+		Name: "Grass", Flags: 1, Color: RGB{R: 10, G: 20, B: 30},
+		VariationsX: 1, VariationsY: 2, Frames: 1,
+		Images: []ImageRef{{Ind: 1}, {Ind: 2}},
+	}},
+	Edges: []Edge{{
+		Name: "GrassEdge", Variations: 1,
+		Sides:   []EdgeDirection{{Variants: []ImageRef{{Ind: 3}, {Ind: 4}}}},
+		Corners: []EdgeDirection{{Variants: []ImageRef{{Ind: 5}, {Ind2: 1, Name: "Corner"}}}},
+	}},
+	Walls: []Wall{{
+		Name: "StoneWall", Unk4: 3,
+		Debris:     []string{"Rock1", "Rock2"},
+		OpenSound:  "WallOpen",
+		Variations: 1,
+		Directions: [15]WallDirection{
+			0: {Variants: []WallVariant{{Images: [4]WallImage{
+				{Pt: image.Point{X: 1, Y: 2}, Img: ImageRef{Ind: 6}},
+				{Img: ImageRef{Ind: 7}},
+				{Img: ImageRef{Ind: 8}},
+				{Img: ImageRef{Ind: 9}},
+			}}}},
+		},
+	}},
+	Audio: []Audio{{
+		Name: "WallOpen", Volume: 100, Samples: []string{"open1", "open2"},
+	}},
+	AudioEvents: []AudioEvent{{
+		Name: "WallOpen",
+		Params: []AudioEventParam{
+			{Type: 1, Data: []byte{5}},
+			{Type: AudioEventSounds, Sounds: []string{"open1"}},
+		},
+	}},
+	Spells: []Spell{{
+		ID: "SPELL_BLINK", ManaCost: 10, Price: 500,
+		Phonemes: []spell.Phoneme{spell.PhonKA, spell.PhonUN},
+		Icon:     &ImageRef{Ind: 10}, IconEnabled: &ImageRef{Ind: 11},
+		Title: "thing.db:Blink", Desc: "thing.db:BlinkDesc",
+		CastSound: "BlinkCast",
+	}},
+	Abilities: []Ability{{
+		ID: "ABILITY_WARCRY", Delay: 5,
+		Icon: &ImageRef{Ind: 12}, IconEnabled: &ImageRef{Ind: 13}, IconActive: &ImageRef{Ind: 14},
+		Title: "thing.db:WarCry", Desc: "thing.db:WarCryDesc",
+	}},
+	Images: []Image{
+		{Name: "Static", Img: &ImageRef{Ind: 15}},
+		{Name: "Anim", Ani: &Animation{Field: 2, Kind: AnimationLoop, Frames: []ImageRef{{Ind: 16}, {Ind: 17}}}},
+	},
+	Things: []Thing{
+		{
+			Name:       "Rock",
+			PrettyName: "thing.db:Rock",
+			Class:      []Class{"IMMOBILE"},
+			Flags:      []Flag{"NO_UPDATE", "SHADOW"},
+			Health:     intPtr(50),
+			Size:       &image.Point{X: 10, Y: 12},
+			ZSize:      &ZSize{Bottom: 0, Top: 30},
+			Extent:     Box{W: 10, H: 12.5},
+			Mass:       0.5,
+			Menu:       &ImageRef{Ind: 18},
+			LightColor: &RGB{R: 1, G: 2, B: 3},
+			Draw:       StaticDraw{Img: ImageRef{Ind: 19}},
+			OnCreate:   &ProcFunc{Name: "Create"},
+			OnUse:      &ProcFunc{Name: "Use", Args: []string{"1", "2"}},
+		},
+		{
+			Name:   "Torch",
+			Extent: Circle{R: 4},
+			Draw: ConditionalAnimateDraw{Anims: []Animation{
+				{Kind: AnimationLoop, Frames: []ImageRef{{Ind: 20}}},
+				{Kind: AnimationOneShot, Frames: []ImageRef{{Ind2: 2, Name: "TorchOff"}}},
+			}},
+		},
+		{
+			Name:   "Bat",
+			Extent: Center{},
+			Draw: MonsterDraw{Anims: []MonsterAnimation{{
+				Type: MonsterAnimIdle, Sound: "BatIdle", FramesPerDir: 1, Kind: AnimationLoop,
+				Frames: [8][]ImageRef{{{Ind: 21}}, {{Ind: 22}}, {{Ind: 23}}, {{Ind: 24}}, {{Ind: 25}}, {{Ind: 26}}, {{Ind: 27}}, {{Ind: 28}}},
+			}}},
+		},
+		{
+			Name: "NewPlayer",
+			Draw: PlayerDraw{Anims: map[player.AnimType]*PlayerAnim{
+				"IDLE": {FramesPerDir: 1, Field12: "loop", Parts: map[player.AnimPart][8][]ImageRef{
+					"NAKED": {{{Ind: 31}}, {{Ind: 32}}, {{Ind: 33}}, {{Ind: 34}}, {{Ind: 35}}, {{Ind: 36}}, {{Ind: 37}}, {{Ind: 38}}},
+				}},
+			}},
+		},
+		{
+			Name: "Lever",
+			Draw: AnimateStateDraw{Anims: []StateAnimation{
+				{State: 1, Name: "ON", Sound: "LeverOn", Anim: Animation{Kind: AnimationOneShot, Frames: []ImageRef{{Ind: 40}, {Ind: 41}}}},
+				{State: 2, Name: "OFF", Anim: Animation{Field: 1, Kind: AnimationLoop, Frames: []ImageRef{{Ind: 42}}}},
+			}},
+		},
+		{
+			Name: "Fireball",
+			Draw: VectorAnimateDraw{Anim: VectorAnimation{
+				FramesPerDir: 1, Kind: AnimationLoop,
+				Frames: [8][]ImageRef{{{Ind: 51}}, {{Ind: 52}}, {{Ind: 53}}, {{Ind: 54}}, {{Ind: 55}}, {{Ind: 56}}, {{Ind: 57}}, {{Ind: 58}}},
+			}},
+		},
+		{
+			Name: "Soul",
+			Draw: ReleasedSoulDraw{Anim: VectorAnimation{
+				FramesPerDir: 2, Field: 3, Kind: AnimationOneShotRemove,
+				Frames: [8][]ImageRef{
+					{{Ind: 61}, {Ind: 62}}, {{Ind: 61}, {Ind: 62}}, {{Ind: 61}, {Ind: 62}}, {{Ind: 61}, {Ind: 62}},
+					{{Ind: 61}, {Ind: 62}}, {{Ind: 61}, {Ind: 62}}, {{Ind: 61}, {Ind: 62}}, {{Ind: 61}, {Ind: 62}},
+				},
+			}},
+		},
+		{Name: "Slave", Draw: SlaveDraw{Imgs: []ImageRef{{Ind: 70}, {Ind: 71}}}},
+		{Name: "Boulder", Draw: BoulderDraw{Imgs: []ImageRef{{Ind: 72}}}},
+		{Name: "Arrow", Draw: ArrowDraw{Imgs: []ImageRef{{Ind: 73}, {Ind2: 1, Name: "ArrowNE"}}}},
+		{Name: "WeakArrow", Draw: WeakArrowDraw{Imgs: []ImageRef{{Ind: 74}}}},
+		{Name: "Harpoon", Draw: HarpoonDraw{Imgs: []ImageRef{{Ind: 75}}}},
+		{
+			Name: "Custom",
+			Draw: UnknownDraw{Type: "CustomDraw", Data: []byte{1, 0xff, 0, 0, 0}},
+		},
+	},
+}
+
+func writeTestData(t testing.TB, d *Data) []byte {
+	var buf bytes.Buffer
+	w, err := CreateWriter(&buf, 0)
+	must.NoError(t, err)
+	err = w.WriteAll(d)
+	must.NoError(t, err)
+	err = w.Close()
+	must.NoError(t, err)
+	return buf.Bytes()
+}
+
+func mustJSON(t testing.TB, v any) string {
+	data, err := json.MarshalIndent(v, "", "\t")
+	must.NoError(t, err)
+	return string(data)
+}
+
+func TestWriteAll(t *testing.T) {
+	data := writeTestData(t, testWriterData)
+
+	r, err := OpenReader(bytes.NewReader(data), 0)
+	must.NoError(t, err)
+	got, err := r.ReadAll()
+	must.NoError(t, err)
+	// reader allocates empty slices in a few places, thus compare JSON
+	must.EqOp(t, mustJSON(t, testWriterData), mustJSON(t, got))
+
+	// writing the data again must produce identical file
+	data2 := writeTestData(t, got)
+	must.Eq(t, data, data2)
+}
+
+func TestThingJSON(t *testing.T) {
+	data, err := json.Marshal(testWriterData.Things)
+	must.NoError(t, err)
+	var got []Thing
+	err = json.Unmarshal(data, &got)
+	must.NoError(t, err)
+	must.Eq(t, testWriterData.Things, got)
+}
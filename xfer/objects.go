@@ -1,5 +1,7 @@
 package xfer
 
+import "github.com/noxworld-dev/opennox-lib/things"
+
 // StaticRegistry stores static XFER decoder mappings for Nox objects. See DefaultRegistry.
 type StaticRegistry struct {
 	ByType map[string]Type
@@ -13,3 +15,61 @@ func (r *StaticRegistry) XferByObjectType(typ string) Type {
 func (r *StaticRegistry) XferByObjectTypeID(id int) Type {
 	return r.ByID[id]
 }
+
+var _ ObjectRegistry = (*DataRegistry)(nil)
+
+// DataRegistry is an ObjectRegistry built from object definitions in Nox thing.bin. See NewDataRegistry.
+type DataRegistry struct {
+	StaticRegistry
+	// Fallback is used for object type names that are not defined in thing.bin. It can be nil.
+	//
+	// Object type IDs depend on the order of definitions in thing.bin, thus unknown IDs never use the fallback.
+	Fallback ObjectRegistry
+}
+
+// NewDataRegistry creates a registry from object definitions in thing.bin.
+// XFER Type is taken from the XFER function of each object.
+// Objects without it are resolved by name using fallback registry, or use DefaultType otherwise.
+// Object type IDs are assigned in the order of definitions in thing.bin, starting from 1.
+//
+// If fallback is set, it will also be used for object type names which are missing in thing.bin.
+// Usually it's set to DefaultRegistry.
+func NewDataRegistry(d *things.Data, fallback ObjectRegistry) *DataRegistry {
+	r := &DataRegistry{
+		StaticRegistry: StaticRegistry{
+			ByType: make(map[string]Type, len(d.Things)),
+			ByID:   make(map[int]Type, len(d.Things)),
+		},
+		Fallback: fallback,
+	}
+	for i, th := range d.Things {
+		var typ Type
+		if th.OnXfer != nil && th.OnXfer.Name != "" {
+			typ = Type(th.OnXfer.Name)
+		} else if fallback != nil {
+			typ = fallback.XferByObjectType(th.Name)
+		}
+		if typ == "" {
+			typ = DefaultType
+		}
+		r.ByID[i+1] = typ
+		if _, ok := r.ByType[th.Name]; !ok {
+			r.ByType[th.Name] = typ
+		}
+	}
+	return r
+}
+
+func (r *DataRegistry) XferByObjectType(typ string) Type {
+	if t := r.StaticRegistry.XferByObjectType(typ); t != "" {
+		return t
+	}
+	if r.Fallback != nil {
+		return r.Fallback.XferByObjectType(typ)
+	}
+	return ""
+}
+
+func (r *DataRegistry) XferByObjectTypeID(id int) Type {
+	return r.StaticRegistry.XferByObjectTypeID(id)
+}
@@ -0,0 +1,49 @@
+package xfer
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+
+	"github.com/noxworld-dev/opennox-lib/things"
+)
+
+func TestDataRegistry(t *testing.T) {
+	d := &things.Data{Things: []things.Thing{
+		{Name: "Rock"},
+		{Name: "ModSword", OnXfer: &things.ProcFunc{Name: "WeaponXfer"}},
+		{Name: "ModBat", OnXfer: &things.ProcFunc{Name: "MonsterXfer"}},
+	}}
+
+	reg := NewDataRegistry(d, nil)
+	must.EqOp(t, DefaultType, reg.XferByObjectType("Rock"))
+	must.EqOp(t, "WeaponXfer", reg.XferByObjectType("ModSword"))
+	must.EqOp(t, "MonsterXfer", reg.XferByObjectType("ModBat"))
+	must.EqOp(t, "", reg.XferByObjectType("AlbinoSpider"))
+	must.EqOp(t, DefaultType, reg.XferByObjectTypeID(1))
+	must.EqOp(t, "WeaponXfer", reg.XferByObjectTypeID(2))
+	must.EqOp(t, "MonsterXfer", reg.XferByObjectTypeID(3))
+	must.EqOp(t, "", reg.XferByObjectTypeID(4))
+
+	reg = NewDataRegistry(d, DefaultRegistry)
+	must.EqOp(t, "MonsterXfer", reg.XferByObjectType("AlbinoSpider"))
+	must.EqOp(t, "WeaponXfer", reg.XferByObjectTypeID(2))
+	// numeric IDs depend on thing.bin order, so unknown IDs must not use the fallback
+	must.NotEq(t, "", DefaultRegistry.XferByObjectTypeID(4))
+	must.EqOp(t, "", reg.XferByObjectTypeID(4))
+}
+
+func TestDataRegistryReordered(t *testing.T) {
+	// vanilla objects in a different order and without XFER functions
+	d := &things.Data{Things: []things.Thing{
+		{Name: "AlchemistDesk1"},
+		{Name: "AlbinoSpider"},
+		{Name: "AbilityBook"},
+	}}
+	reg := NewDataRegistry(d, DefaultRegistry)
+	must.EqOp(t, DefaultType, reg.XferByObjectTypeID(1))
+	must.EqOp(t, "MonsterXfer", reg.XferByObjectTypeID(2))
+	must.EqOp(t, "AbilityRewardXfer", reg.XferByObjectTypeID(3))
+	must.EqOp(t, "", reg.XferByObjectTypeID(4))
+	must.EqOp(t, "MonsterXfer", reg.XferByObjectType("AlbinoSpider"))
+}